@@ -0,0 +1,353 @@
+package gg
+
+import (
+	"sync"
+	"time"
+)
+
+// Type-inferring shortcut for creating a `LruCache` of the given type.
+func LruCacheOf[
+	Key comparable,
+	Val any,
+	Ptr Initer1Ptr[Val, Key],
+]() *LruCache[Key, Val, Ptr] {
+	return new(LruCache[Key, Val, Ptr])
+}
+
+/*
+Bounded variant of `Cache`. Concurrency-safe cache that creates and initializes
+values on demand, using keys as inputs, with optional limits:
+
+  - `.Max` limits the amount of entries. When exceeded, the least recently used
+    entries are evicted.
+  - `.Ttl` limits the lifetime of entries. Expiration is lazy: expired entries
+    are removed when accessed, when evicting, or via `LruCache.Prune`, and are
+    then re-initialized on demand.
+
+Statistics are available via `LruCache.Stats`. Entries removed due to limits are
+reported to `.OnEvict`, if any.
+
+Like in `Cache`, for any given key, the value is initialized exactly once, even
+if multiple goroutines are trying to access it simultaneously. Unlike `Cache`,
+initialization doesn't block access to other keys. After an entry is evicted or
+expires, it's initialized again on the next access.
+
+The configuration fields must be set before first use, and must not be modified
+afterwards. Zero value is ready to use, and has no limits. Contains a
+synchronization primitive and must not be copied after first use.
+*/
+type LruCache[
+	Key comparable,
+	Val any,
+	Ptr Initer1Ptr[Val, Key],
+] struct {
+	/**
+	When positive, limits the amount of entries. Entries which are still being
+	initialized are never evicted, and may temporarily exceed the limit.
+	*/
+	Max int
+
+	/**
+	When non-zero, entries expire after this duration, counting from the end of
+	initialization. Like in `Mem`, negative durations cause entries to expire
+	immediately. If the value implements `Durationer` on its pointer type, its
+	duration overrides this one, with the same semantics.
+	*/
+	Ttl time.Duration
+
+	/**
+	Called after entries are removed due to `.Max` or `.Ttl`, outside of the
+	lock. Not called for entries removed via `LruCache.Del` or
+	`LruCache.Clear`.
+	*/
+	OnEvict func(Key, Ptr)
+
+	// Source of time for expiration. If nil, `ClockReal` is used.
+	Clock Clock
+
+	lock  sync.Mutex
+	ents  map[Key]*lruEnt[Key, Val, Ptr]
+	head  *lruEnt[Key, Val, Ptr]
+	tail  *lruEnt[Key, Val, Ptr]
+	stats CacheStats
+}
+
+// Statistics of `LruCache`, see `LruCache.Stats`.
+type CacheStats struct {
+	// Accesses which found a ready or pending entry.
+	Hits int
+
+	// Accesses which created a new entry.
+	Misses int
+
+	// Entries removed due to `LruCache.Max`.
+	Evictions int
+
+	// Entries removed due to `LruCache.Ttl`.
+	Expirations int
+}
+
+/*
+Shortcut for using `.Ptr` and dereferencing the result. May be invalid if the
+resulting value is non-copyable, for example when it contains a mutex.
+*/
+func (self *LruCache[Key, Val, Ptr]) Get(key Key) Val { return *self.Ptr(key) }
+
+/*
+Returns the cached value for the given key, by pointer. If the value is missing
+or expired, idempotently initializes it by calling `.Init` on a new value and
+caches the result, possibly evicting other entries. If initialization panics,
+the entry is discarded, and every goroutine waiting for it panics with the same
+error, each with its own stack trace added.
+*/
+func (self *LruCache[Key, Val, Ptr]) Ptr(key Key) Ptr {
+	ent, created := self.ent(key)
+	if created {
+		self.init(ent)
+	}
+
+	<-ent.done
+	TryErr(WrapTracedAt(ent.err, 1))
+	return ent.val
+}
+
+/*
+Returns the cached value for the given key, if it's ready and not expired,
+without initializing it. Doesn't affect the usage order or statistics.
+*/
+func (self *LruCache[Key, _, Ptr]) Peek(key Key) (Ptr, bool) {
+	defer Lock(&self.lock).Unlock()
+
+	ent := self.ents[key]
+	if ent == nil || !ent.ready || self.expired(ent, self.now()) {
+		return nil, false
+	}
+	return ent.val, true
+}
+
+// Deletes the value for the given key. Doesn't call `.OnEvict`.
+func (self *LruCache[Key, _, _]) Del(key Key) {
+	defer Lock(&self.lock).Unlock()
+
+	ent := self.ents[key]
+	if ent != nil {
+		self.remove(ent)
+	}
+}
+
+/*
+Deletes all values, without calling `.OnEvict`. Doesn't reset the statistics.
+Goroutines which are already waiting for pending values still receive them.
+*/
+func (self *LruCache[_, _, _]) Clear() {
+	defer Lock(&self.lock).Unlock()
+	self.ents = nil
+	self.head = nil
+	self.tail = nil
+}
+
+/*
+Returns the amount of entries, including expired entries which haven't been
+removed yet, and pending entries which are being initialized.
+*/
+func (self *LruCache[_, _, _]) Len() int {
+	defer Lock(&self.lock).Unlock()
+	return len(self.ents)
+}
+
+// Returns a snapshot of the statistics.
+func (self *LruCache[_, _, _]) Stats() CacheStats {
+	defer Lock(&self.lock).Unlock()
+	return self.stats
+}
+
+/*
+Removes all expired entries, reporting them to `.OnEvict`. Since expiration is
+lazy, this can be called periodically to release memory held by entries which
+are no longer accessed.
+*/
+func (self *LruCache[_, _, _]) Prune() {
+	self.evicted(self.prune())
+}
+
+func (self *LruCache[Key, Val, Ptr]) prune() (out []*lruEnt[Key, Val, Ptr]) {
+	defer Lock(&self.lock).Unlock()
+
+	now := self.now()
+	for ent := self.tail; ent != nil; {
+		prev := ent.prev
+		if ent.ready && self.expired(ent, now) {
+			self.remove(ent)
+			self.stats.Expirations++
+			out = append(out, ent)
+		}
+		ent = prev
+	}
+	return
+}
+
+/*
+Returns the entry for the given key, creating it if missing or expired. True
+means the entry must be initialized by the caller. Reports removed entries to
+`.OnEvict`.
+*/
+func (self *LruCache[Key, Val, Ptr]) ent(key Key) (*lruEnt[Key, Val, Ptr], bool) {
+	ent, created, evicted := self.entLocked(key)
+	self.evicted(evicted)
+	return ent, created
+}
+
+func (self *LruCache[Key, Val, Ptr]) entLocked(key Key) (
+	ent *lruEnt[Key, Val, Ptr],
+	created bool,
+	evicted []*lruEnt[Key, Val, Ptr],
+) {
+	defer Lock(&self.lock).Unlock()
+
+	ent = self.ents[key]
+	if ent != nil {
+		if !(ent.ready && self.expired(ent, self.now())) {
+			self.stats.Hits++
+			self.unlink(ent)
+			self.link(ent)
+			return
+		}
+
+		self.remove(ent)
+		self.stats.Expirations++
+		evicted = append(evicted, ent)
+	}
+
+	self.stats.Misses++
+	ent = &lruEnt[Key, Val, Ptr]{key: key, done: make(chan struct{})}
+	MapInit(&self.ents)[key] = ent
+	self.link(ent)
+	created = true
+
+	for self.Max > 0 && len(self.ents) > self.Max {
+		tar := self.lru()
+		if tar == nil {
+			break
+		}
+
+		self.remove(tar)
+		if self.expired(tar, self.now()) {
+			self.stats.Expirations++
+		} else {
+			self.stats.Evictions++
+		}
+		evicted = append(evicted, tar)
+	}
+	return
+}
+
+func (self *LruCache[Key, Val, Ptr]) init(ent *lruEnt[Key, Val, Ptr]) {
+	defer self.inited(ent)
+	defer Rec(&ent.err)
+
+	ptr := Ptr(new(Val))
+	ptr.Init(ent.key)
+	ent.val = ptr
+}
+
+/*
+Marks the entry as ready, starting its lifetime, and wakes up the waiters. If
+initialization has failed, removes the entry instead, unless it was already
+removed or replaced.
+*/
+func (self *LruCache[Key, Val, Ptr]) inited(ent *lruEnt[Key, Val, Ptr]) {
+	defer close(ent.done)
+	defer Lock(&self.lock).Unlock()
+
+	if ent.err != nil {
+		if self.ents[ent.key] == ent {
+			self.remove(ent)
+		}
+		return
+	}
+
+	ent.ready = true
+	ent.inst = self.now()
+}
+
+func (self *LruCache[Key, Val, Ptr]) evicted(src []*lruEnt[Key, Val, Ptr]) {
+	fun := self.OnEvict
+	if fun == nil {
+		return
+	}
+	for _, ent := range src {
+		fun(ent.key, ent.val)
+	}
+}
+
+// Returns the least recently used entry which is ready for eviction.
+func (self *LruCache[Key, Val, Ptr]) lru() *lruEnt[Key, Val, Ptr] {
+	for ent := self.tail; ent != nil; ent = ent.prev {
+		if ent.ready {
+			return ent
+		}
+	}
+	return nil
+}
+
+func (self *LruCache[_, _, _]) now() time.Time { return ClockOr(self.Clock).Now() }
+
+func (self *LruCache[Key, Val, Ptr]) expired(ent *lruEnt[Key, Val, Ptr], now time.Time) bool {
+	dur := self.Ttl
+	impl, ok := any(ent.val).(Durationer)
+	if ok {
+		dur = impl.Duration()
+	}
+	return dur != 0 && ent.inst.Add(dur).Before(now)
+}
+
+func (self *LruCache[Key, Val, Ptr]) remove(ent *lruEnt[Key, Val, Ptr]) {
+	delete(self.ents, ent.key)
+	self.unlink(ent)
+}
+
+// Adds the entry to the head of the usage list.
+func (self *LruCache[Key, Val, Ptr]) link(ent *lruEnt[Key, Val, Ptr]) {
+	ent.prev = nil
+	ent.next = self.head
+	if self.head != nil {
+		self.head.prev = ent
+	}
+	self.head = ent
+	if self.tail == nil {
+		self.tail = ent
+	}
+}
+
+func (self *LruCache[Key, Val, Ptr]) unlink(ent *lruEnt[Key, Val, Ptr]) {
+	if ent.prev != nil {
+		ent.prev.next = ent.next
+	} else if self.head == ent {
+		self.head = ent.next
+	}
+
+	if ent.next != nil {
+		ent.next.prev = ent.prev
+	} else if self.tail == ent {
+		self.tail = ent.prev
+	}
+
+	ent.prev = nil
+	ent.next = nil
+}
+
+/*
+Entry of `LruCache`. The fields `.val` and `.err` are written only by the
+goroutine which initializes the entry, before closing `.done`. The other fields
+are protected by the cache's lock.
+*/
+type lruEnt[Key comparable, Val any, Ptr Initer1Ptr[Val, Key]] struct {
+	key   Key
+	val   Ptr
+	err   error
+	done  chan struct{}
+	ready bool
+	inst  time.Time
+	prev  *lruEnt[Key, Val, Ptr]
+	next  *lruEnt[Key, Val, Ptr]
+}
@@ -0,0 +1,188 @@
+package gg_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+var testLruInits atomic.Int64
+
+type LruVal struct{ Key, Num int }
+
+func (self *LruVal) Init(key int) {
+	if key < 0 {
+		panic(testErrUntracedA)
+	}
+	self.Key = key
+	self.Num = int(testLruInits.Add(1))
+}
+
+type LruValDur struct {
+	LruVal
+	Dur time.Duration
+}
+
+func (self *LruValDur) Init(key int) {
+	self.LruVal.Init(key)
+	self.Dur = time.Duration(key) * time.Second
+}
+
+func (self *LruValDur) Duration() time.Duration { return self.Dur }
+
+func TestLruCache_Max(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var evicted []int
+	cache := gg.LruCacheOf[int, LruVal]()
+	cache.Max = 3
+	cache.OnEvict = func(key int, val *LruVal) {
+		gtest.Eq(val.Key, key)
+		evicted = append(evicted, key)
+	}
+
+	gtest.Eq(cache.Get(10).Key, 10)
+	gtest.Eq(cache.Get(20).Key, 20)
+	gtest.Eq(cache.Get(30).Key, 30)
+	gtest.Eq(cache.Len(), 3)
+	gtest.Zero(evicted)
+
+	num := cache.Get(10).Num
+	gtest.Eq(cache.Get(10).Num, num, `hits must not re-initialize`)
+
+	gtest.Eq(cache.Get(40).Key, 40)
+	gtest.Equal(evicted, []int{20}, `must evict the least recently used entry`)
+	gtest.Eq(cache.Len(), 3)
+
+	_, ok := cache.Peek(20)
+	gtest.False(ok)
+
+	val, ok := cache.Peek(10)
+	gtest.True(ok)
+	gtest.Eq(val.Num, num)
+
+	gtest.Eq(cache.Get(50).Key, 50)
+	gtest.Equal(evicted, []int{20, 30}, `peeking must not affect the usage order`)
+
+	gtest.NotEq(cache.Get(20).Num, 0)
+	gtest.Equal(evicted, []int{20, 30, 10})
+
+	gtest.Equal(cache.Stats(), gg.CacheStats{Hits: 2, Misses: 6, Evictions: 3})
+
+	cache.Del(40)
+	cache.Del(60)
+	gtest.Eq(cache.Len(), 2)
+
+	cache.Clear()
+	gtest.Zero(cache.Len())
+	gtest.Equal(evicted, []int{20, 30, 10}, `explicit deletion must not be reported`)
+}
+
+func TestLruCache_Ttl(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var clock gg.ClockFake
+	var evicted []int
+
+	cache := gg.LruCacheOf[int, LruVal]()
+	cache.Ttl = time.Minute
+	cache.Clock = &clock
+	cache.OnEvict = func(key int, _ *LruVal) { evicted = append(evicted, key) }
+
+	num := cache.Get(10).Num
+	clock.Advance(time.Second * 30)
+	cache.Get(20)
+
+	clock.Advance(time.Second * 30)
+	gtest.Eq(cache.Get(10).Num, num, `must not expire at the exact deadline`)
+
+	clock.Advance(time.Second)
+	_, ok := cache.Peek(10)
+	gtest.False(ok)
+	gtest.Eq(cache.Len(), 2, `expiration must be lazy`)
+
+	gtest.NotEq(cache.Get(10).Num, num)
+	gtest.Equal(evicted, []int{10})
+
+	clock.Advance(time.Second * 30)
+	cache.Prune()
+	gtest.Equal(evicted, []int{10, 20})
+	gtest.Eq(cache.Len(), 1)
+
+	gtest.Equal(cache.Stats(), gg.CacheStats{Hits: 1, Misses: 3, Expirations: 2})
+}
+
+func TestLruCache_Durationer(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var clock gg.ClockFake
+	cache := gg.LruCacheOf[int, LruValDur]()
+	cache.Ttl = time.Hour
+	cache.Clock = &clock
+
+	one := cache.Get(1).Num
+	two := cache.Get(2).Num
+	never := cache.Get(0).Num
+
+	clock.Advance(time.Second + 1)
+	gtest.NotEq(cache.Get(1).Num, one)
+	gtest.Eq(cache.Get(2).Num, two)
+
+	clock.Advance(time.Hour * 24)
+	gtest.Eq(cache.Get(0).Num, never, `zero duration must never expire`)
+}
+
+func TestLruCache_Ptr_concurrent(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var inits atomic.Int64
+	var gro sync.WaitGroup
+	cache := gg.LruCacheOf[int, LruValSlow]()
+	cache.Max = 4
+
+	for ind := range gg.Span(64) {
+		ind := ind
+		gro.Add(1)
+		go func() {
+			defer gro.Done()
+			gtest.Eq(cache.Get(ind%2).Key, ind%2)
+		}()
+	}
+	gro.Wait()
+
+	for _, key := range []int{0, 1} {
+		val, ok := cache.Peek(key)
+		gtest.True(ok)
+		inits.Add(int64(val.Inits))
+	}
+
+	stats := cache.Stats()
+	gtest.Eq(stats.Misses, 2, `must initialize each key exactly once`)
+	gtest.Eq(stats.Hits, 62)
+	gtest.Eq(inits.Load(), 2)
+}
+
+type LruValSlow struct{ Key, Inits int }
+
+func (self *LruValSlow) Init(key int) {
+	time.Sleep(time.Millisecond)
+	self.Key = key
+	self.Inits++
+}
+
+func TestLruCache_Ptr_panic(t *testing.T) {
+	defer gtest.Catch(t)
+
+	cache := gg.LruCacheOf[int, LruVal]()
+
+	err := gg.Catch(func() { cache.Get(-1) })
+	testWrappedErr(err, testErrUntracedA)
+	gtest.Zero(cache.Len(), `failed entries must be discarded`)
+
+	gtest.Eq(cache.Get(10).Key, 10)
+	gtest.Equal(cache.Stats(), gg.CacheStats{Misses: 2})
+}
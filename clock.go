@@ -0,0 +1,109 @@
+package gg
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+Source of time used by time-dependent tools such as `Retry`. Allows to replace
+real time with `ClockFake` in tests. The zero value of `ClockReal` uses real
+time.
+*/
+type Clock interface {
+	Now() time.Time
+	Sleep(ctx context.Context, dur time.Duration) error
+}
+
+/*
+Returns the given clock if non-nil, or `ClockReal{}` otherwise. Used by tools
+which have an optional `Clock` field.
+*/
+func ClockOr(val Clock) Clock {
+	if val != nil {
+		return val
+	}
+	return ClockReal{}
+}
+
+// Implementation of `Clock` which uses real time.
+type ClockReal struct{}
+
+// Implement `Clock` by calling `time.Now`.
+func (ClockReal) Now() time.Time { return time.Now() }
+
+/*
+Implement `Clock`. Waits for the given duration or until the context is done,
+whichever comes first. In the latter case, returns the context's error.
+*/
+func (ClockReal) Sleep(ctx context.Context, dur time.Duration) error {
+	err := ctx.Err()
+	if err != nil || dur <= 0 {
+		return err
+	}
+
+	timer := time.NewTimer(dur)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+/*
+Implementation of `Clock` for deterministic tests. Time moves only when
+advanced via `.Sleep`, `.Advance`, or `.SetNow`. Sleeping returns immediately,
+advancing the time, and is recorded, see `.Sleeps`. Safe for concurrent use.
+The zero value is ready to use, starting at the zero time.
+*/
+type ClockFake struct {
+	lock   sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// Implement `Clock`, returning the current fake time.
+func (self *ClockFake) Now() time.Time {
+	defer Lock(&self.lock).Unlock()
+	return self.now
+}
+
+/*
+Implement `Clock`. If the context is done, returns its error. Otherwise records
+the duration and advances the time by it, without actually waiting.
+*/
+func (self *ClockFake) Sleep(ctx context.Context, dur time.Duration) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	defer Lock(&self.lock).Unlock()
+	self.sleeps = append(self.sleeps, dur)
+	if dur > 0 {
+		self.now = self.now.Add(dur)
+	}
+	return nil
+}
+
+// Advances the fake time by the given duration.
+func (self *ClockFake) Advance(dur time.Duration) {
+	defer Lock(&self.lock).Unlock()
+	self.now = self.now.Add(dur)
+}
+
+// Sets the fake time.
+func (self *ClockFake) SetNow(val time.Time) {
+	defer Lock(&self.lock).Unlock()
+	self.now = val
+}
+
+// Returns a copy of the durations passed to `.Sleep` so far.
+func (self *ClockFake) Sleeps() []time.Duration {
+	defer Lock(&self.lock).Unlock()
+	return Clone(self.sleeps)
+}
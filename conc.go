@@ -266,6 +266,7 @@ func closeConcCtx(gro *sync.WaitGroup, errChan chan error) {
 func recSend(tar chan error) {
 	err := AnyErrTracedAt(recover(), 1)
 	if err != nil {
+		recObserve(err, false)
 		SendOpt(tar, err)
 	}
 }
@@ -0,0 +1,338 @@
+package gg
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+Configures `ConcEachLim`, `ConcMapLim` and similar functions, which run a
+function on each element of a slice with bounded concurrency. The zero value
+has no limit, and behaves like `ConcEach` and `ConcMap`: one goroutine per
+element.
+*/
+type ConcLim struct {
+	/**
+	When positive, limits how many goroutines run concurrently. Each goroutine
+	processes chunks one by one until there are none left.
+	*/
+	Max int
+
+	/**
+	When positive, elements are split into chunks of this size, and the
+	elements of each chunk are processed sequentially by one goroutine. Useful
+	for cheap functions and large slices, where the overhead of scheduling each
+	element separately would dominate.
+	*/
+	Chunk int
+
+	/**
+	If true, after the first failure, elements which haven't started are
+	skipped. Their errors wrap `context.Canceled`, can be detected via
+	`errors.Is`, and have no stack traces. Elements which are already running
+	are not interrupted. Context-aware variants such as `ConcEachCtx` always
+	behave this way.
+	*/
+	FailFast bool
+}
+
+/*
+Like `ConcEach` but with bounded concurrency, see `ConcLim`. If any calls
+panic, panics with the combined error. Errors of skipped elements, see
+`ConcLim.FailFast`, are not included.
+*/
+func ConcEachLim[A any](lim ConcLim, src []A, fun func(A)) {
+	TryErr(concLimErr(concEachLim(lim, src, fun), 1))
+}
+
+/*
+Like `ConcEachCatch` but with bounded concurrency, see `ConcLim`. Collects
+panics from each call as error values, at the same indexes as the elements.
+
+Ensures that the resulting errors have stack traces, combining the traces from
+goroutines spawned for the given functions (if necessary) with the trace from
+the calling goroutine.
+
+If `error` is desired instead of `[]error`, use `ErrMul` to convert correctly.
+*/
+func ConcEachLimCatch[A any](lim ConcLim, src []A, fun func(A)) []error {
+	errs := concEachLim(lim, src, fun)
+	concLimWrap(errs, 1)
+	return errs
+}
+
+func concEachLim[A any](lim ConcLim, src []A, fun func(A)) []error {
+	if fun == nil || len(src) <= 0 {
+		return nil
+	}
+	return lim.run(nil, nil, len(src), func(ind int) { fun(src[ind]) })
+}
+
+/*
+Like `ConcMap` but with bounded concurrency, see `ConcLim`. The order of
+outputs matches the order of inputs. If any calls panic, panics with the
+combined error. Errors of skipped elements, see `ConcLim.FailFast`, are not
+included.
+*/
+func ConcMapLim[A, B any](lim ConcLim, src []A, fun func(A) B) []B {
+	vals, errs := concMapLim(lim, src, fun)
+	TryErr(concLimErr(errs, 1))
+	return vals
+}
+
+/*
+Like `ConcMapCatch` but with bounded concurrency, see `ConcLim`. The order of
+outputs matches the order of inputs. Returns the resulting values along with
+the caught panics, if any, at the same indexes as the elements. For failed and
+skipped elements, the values are zero.
+
+Ensures that the resulting errors have stack traces, combining the traces from
+goroutines spawned for the given functions (if necessary) with the trace from
+the calling goroutine.
+*/
+func ConcMapLimCatch[A, B any](lim ConcLim, src []A, fun func(A) B) ([]B, []error) {
+	vals, errs := concMapLim(lim, src, fun)
+	concLimWrap(errs, 1)
+	return vals, errs
+}
+
+func concMapLim[A, B any](lim ConcLim, src []A, fun func(A) B) ([]B, []error) {
+	if fun == nil || len(src) <= 0 {
+		return nil, nil
+	}
+
+	vals := make([]B, len(src))
+	errs := lim.run(nil, nil, len(src), func(ind int) { vals[ind] = fun(src[ind]) })
+	return vals, errs
+}
+
+/*
+Like `ConcEachLim`, but passes a context to each call. The context is derived
+from the given context, and is canceled on the first failure, after which
+elements which haven't started are skipped, regardless of `ConcLim.FailFast`.
+When the parent context is done, remaining elements are skipped as well.
+If the given context is nil, `context.Background` is used.
+
+If any calls panic, panics with the combined error, excluding skipped elements.
+If there are no failures, but some elements were skipped because the parent
+context is done, panics with an error wrapping the context's error.
+*/
+func ConcEachCtx[A any](ctx context.Context, lim ConcLim, src []A, fun func(context.Context, A)) {
+	TryErr(concLimErr(concEachCtx(ctx, lim, src, fun), 1))
+}
+
+/*
+Like `ConcEachLimCatch`, but passes a context to each call, see `ConcEachCtx`.
+Collects panics from each call as error values, at the same indexes as the
+elements. The errors of skipped elements wrap the error of the context, which is
+`context.Canceled` when skipping is due to a failure, and can be detected via
+`errors.Is`.
+*/
+func ConcEachCtxCatch[A any](ctx context.Context, lim ConcLim, src []A, fun func(context.Context, A)) []error {
+	errs := concEachCtx(ctx, lim, src, fun)
+	concLimWrap(errs, 1)
+	return errs
+}
+
+func concEachCtx[A any](ctx context.Context, lim ConcLim, src []A, fun func(context.Context, A)) []error {
+	if fun == nil || len(src) <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctxOr(ctx))
+	defer cancel()
+	return lim.run(ctx, cancel, len(src), func(ind int) { fun(ctx, src[ind]) })
+}
+
+/*
+Like `ConcMapLim`, but passes a context to each call. Cancelation and errors
+work like in `ConcEachCtx`. The order of outputs matches the order of inputs.
+*/
+func ConcMapCtx[A, B any](ctx context.Context, lim ConcLim, src []A, fun func(context.Context, A) B) []B {
+	vals, errs := concMapCtx(ctx, lim, src, fun)
+	TryErr(concLimErr(errs, 1))
+	return vals
+}
+
+/*
+Like `ConcMapLimCatch`, but passes a context to each call. Cancelation and
+errors work like in `ConcEachCtxCatch`. For failed and skipped elements, the
+values are zero.
+*/
+func ConcMapCtxCatch[A, B any](ctx context.Context, lim ConcLim, src []A, fun func(context.Context, A) B) ([]B, []error) {
+	vals, errs := concMapCtx(ctx, lim, src, fun)
+	concLimWrap(errs, 1)
+	return vals, errs
+}
+
+func concMapCtx[A, B any](ctx context.Context, lim ConcLim, src []A, fun func(context.Context, A) B) ([]B, []error) {
+	if fun == nil || len(src) <= 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctxOr(ctx))
+	defer cancel()
+
+	vals := make([]B, len(src))
+	errs := lim.run(ctx, cancel, len(src), func(ind int) { vals[ind] = fun(ctx, src[ind]) })
+	return vals, errs
+}
+
+/*
+Error of elements which were skipped without being started. Shared between all
+such elements of one call, which avoids allocating an error for each of them.
+*/
+type errConcSkip struct{ Err }
+
+func isErrConcSkip(err error) bool {
+	_, ok := err.(errConcSkip)
+	return ok
+}
+
+// Error of elements skipped due to an earlier failure.
+var errConcSkipped error = errConcSkip{Err{
+	Msg:   `skipped due to an earlier failure`,
+	Cause: context.Canceled,
+}}
+
+/*
+Calls the given function for each index in `[0,size)` according to the limits,
+converting panics to errors. Runs on the current goroutine when only one worker
+is needed. If the context is non-nil, elements are skipped once it's done, and
+the first failure calls the given cancel function.
+*/
+func (self ConcLim) run(ctx context.Context, cancel func(), size int, fun func(int)) []error {
+	chunk := MaxPrim2(self.Chunk, 1)
+	state := concLimState{
+		ConcLim: self,
+		ctx:     ctx,
+		cancel:  cancel,
+		fun:     fun,
+		errs:    make([]error, size),
+		chunk:   chunk,
+		chunks:  (size + chunk - 1) / chunk,
+	}
+
+	workers := state.chunks
+	if self.Max > 0 {
+		workers = MinPrim2(workers, self.Max)
+	}
+
+	if workers <= 1 {
+		state.work()
+		return state.errs
+	}
+
+	state.gro.Add(workers)
+	for range Iter(workers) {
+		go state.workAsync()
+	}
+	state.gro.Wait()
+	return state.errs
+}
+
+type concLimState struct {
+	ConcLim
+	ctx     context.Context
+	cancel  func()
+	fun     func(int)
+	errs    []error
+	chunk   int
+	chunks  int
+	next    atomic.Int64
+	failed  atomic.Bool
+	gro     sync.WaitGroup
+	ctxOnce sync.Once
+	ctxErr  error
+}
+
+func (self *concLimState) workAsync() {
+	defer self.gro.Done()
+	self.work()
+}
+
+func (self *concLimState) work() {
+	for {
+		chunk := int(self.next.Add(1) - 1)
+		if chunk >= self.chunks {
+			return
+		}
+
+		start := chunk * self.chunk
+		end := MinPrim2(start+self.chunk, len(self.errs))
+		for ind := start; ind < end; ind++ {
+			self.runInd(ind)
+		}
+	}
+}
+
+func (self *concLimState) runInd(ind int) {
+	if self.FailFast && self.failed.Load() {
+		self.errs[ind] = errConcSkipped
+		return
+	}
+
+	if self.ctx != nil && self.ctx.Err() != nil {
+		self.errs[ind] = self.skipErr()
+		return
+	}
+
+	err := Catch10(self.fun, ind)
+	if err != nil {
+		self.errs[ind] = err
+		self.failed.Store(true)
+		if self.cancel != nil {
+			self.cancel()
+		}
+	}
+}
+
+func (self *concLimState) skipErr() error {
+	if self.failed.Load() {
+		return errConcSkipped
+	}
+	self.ctxOnce.Do(self.initCtxErr)
+	return self.ctxErr
+}
+
+func (self *concLimState) initCtxErr() {
+	self.ctxErr = errConcSkip{Err{
+		Msg:   `skipped because the context is done`,
+		Cause: self.ctx.Err(),
+	}}
+}
+
+/*
+Like `Errs.WrapTracedAt`, but skips the errors of skipped elements, which don't
+need traces, and would be expensive to trace in large slices.
+*/
+func concLimWrap(errs []error, skip int) {
+	for ind, err := range errs {
+		if err != nil && !isErrConcSkip(err) {
+			errs[ind] = WrapTracedAt(err, skip+1)
+		}
+	}
+}
+
+/*
+Combines the errors of failed elements, excluding skipped elements, adding
+stack traces. If there are no failures, but some elements were skipped because
+the context was done, returns that error. Modifies the slice.
+*/
+func concLimErr(errs []error, skip int) error {
+	var skipped error
+	for ind, err := range errs {
+		if isErrConcSkip(err) {
+			skipped = err
+			errs[ind] = nil
+		}
+	}
+
+	concLimWrap(errs, skip+1)
+	err := ErrMul(errs...)
+	if err == nil {
+		return WrapTracedAt(skipped, skip+1)
+	}
+	return err
+}
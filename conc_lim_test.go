@@ -0,0 +1,271 @@
+package gg_test
+
+import (
+	"context"
+	e "errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/grepr"
+	"github.com/mitranim/gg/gtest"
+)
+
+func TestConcMapLimCatch(t *testing.T) {
+	defer gtest.Catch(t)
+
+	src := []int{10, 20, 30, 40, 50}
+
+	test := func(lim gg.ConcLim) {
+		vals, errs := gg.ConcMapLimCatch(lim, src, testConcMapFunc)
+		gtest.Equal(vals, []string{`10`, ``, `30`, `40`, `50`}, grepr.String(lim))
+		testWrappedErrs(errs, []error{nil, testErrTraced1, nil, nil, nil}, grepr.String(lim))
+	}
+
+	test(gg.ConcLim{})
+	test(gg.ConcLim{Max: 1})
+	test(gg.ConcLim{Max: 2})
+	test(gg.ConcLim{Max: 8})
+	test(gg.ConcLim{Chunk: 2})
+	test(gg.ConcLim{Max: 2, Chunk: 2})
+	test(gg.ConcLim{Max: 2, Chunk: 8})
+
+	vals, errs := gg.ConcMapLimCatch[int, string](gg.ConcLim{}, src, nil)
+	gtest.Zero(vals)
+	gtest.Zero(errs)
+
+	vals, errs = gg.ConcMapLimCatch(gg.ConcLim{}, nil, testConcMapFunc)
+	gtest.Zero(vals)
+	gtest.Zero(errs)
+}
+
+func TestConcMapLim(t *testing.T) {
+	defer gtest.Catch(t)
+
+	src := gg.Span(1000)
+	lim := gg.ConcLim{Max: 4, Chunk: 16}
+
+	gtest.Equal(gg.ConcMapLim(lim, src, gg.String[int]), gg.Map(src, gg.String[int]))
+
+	gtest.PanicErrIs(testErrTraced1, func() {
+		gg.ConcMapLim(lim, []int{10, 20, 30}, testConcMapFunc)
+	})
+}
+
+func TestConcEachLim_max(t *testing.T) {
+	defer gtest.Catch(t)
+
+	for _, lim := range []gg.ConcLim{{Max: 1}, {Max: 3}, {Max: 3, Chunk: 4}} {
+		var peak testConcPeak
+		gg.ConcEachLim(lim, gg.Span(64), func(int) { peak.Run(time.Microsecond * 100) })
+
+		gtest.LessEqPrim(peak.peak.Load(), int64(lim.Max), grepr.String(lim))
+		gtest.Zero(peak.running.Load())
+	}
+}
+
+func TestConcEachLimCatch_FailFast(t *testing.T) {
+	defer gtest.Catch(t)
+
+	src := gg.Span(100)
+
+	t.Run(`sequential`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var count int
+		errs := gg.ConcEachLimCatch(gg.ConcLim{Max: 1, FailFast: true}, src, func(val int) {
+			count++
+			if val == 3 {
+				panic(testErrUntracedA)
+			}
+		})
+
+		gtest.Eq(count, 4)
+		gtest.Len(errs, len(src))
+		gtest.Equal(errs[:3], []error{nil, nil, nil})
+		testWrappedErr(errs[3], testErrUntracedA)
+
+		for _, err := range errs[4:] {
+			gtest.ErrIs(err, context.Canceled)
+			gtest.ErrStr(`skipped due to an earlier failure`, err)
+		}
+	})
+
+	t.Run(`concurrent`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var count atomic.Int64
+		errs := gg.ConcEachLimCatch(gg.ConcLim{Max: 4, Chunk: 5, FailFast: true}, src, func(val int) {
+			count.Add(1)
+			if val == 0 {
+				panic(testErrTraced0)
+			}
+			time.Sleep(time.Microsecond * 100)
+		})
+
+		gtest.Len(errs, len(src))
+		testWrappedErr(errs[0], testErrTraced0)
+
+		skipped := gg.Count(errs, func(err error) bool { return e.Is(err, context.Canceled) })
+		gtest.Eq(skipped+int(count.Load()), len(src))
+		gtest.LessPrim(0, skipped)
+	})
+
+	t.Run(`without`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		errs := gg.ConcEachLimCatch(gg.ConcLim{Max: 1}, src, func(val int) {
+			if val%10 == 0 {
+				panic(testErrUntracedA)
+			}
+		})
+
+		gtest.Eq(gg.Count(errs, gg.IsErrNotNil), 10)
+		gtest.False(gg.Some(errs, func(err error) bool { return e.Is(err, context.Canceled) }))
+	})
+}
+
+func TestConcEachLim_FailFast(t *testing.T) {
+	defer gtest.Catch(t)
+
+	err := gg.Catch(func() {
+		gg.ConcEachLim(gg.ConcLim{Max: 1, FailFast: true}, gg.Span(100), func(val int) {
+			if val == 3 {
+				panic(testErrUntracedA)
+			}
+		})
+	})
+
+	testWrappedErr(err, testErrUntracedA)
+	gtest.False(e.Is(err, context.Canceled), `skipped elements must not be reported`)
+}
+
+func BenchmarkConcMapLim(b *testing.B) {
+	src := gg.Span(1024)
+	lim := gg.ConcLim{Max: 8, Chunk: 64}
+
+	for ind := 0; ind < b.N; ind++ {
+		gg.Nop1(gg.ConcMapLim(lim, src, gg.Inc[int]))
+	}
+}
+
+func BenchmarkConcMap_unlimited(b *testing.B) {
+	src := gg.Span(1024)
+
+	for ind := 0; ind < b.N; ind++ {
+		gg.Nop1(gg.ConcMap(src, gg.Inc[int]))
+	}
+}
+
+func TestConcMapCtxCatch(t *testing.T) {
+	defer gtest.Catch(t)
+
+	t.Run(`success`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var ctxs []context.Context
+		vals, errs := gg.ConcMapCtxCatch(context.Background(), gg.ConcLim{Max: 1}, gg.Span(4), func(ctx context.Context, val int) string {
+			ctxs = append(ctxs, ctx)
+			return gg.String(val)
+		})
+
+		gtest.Equal(vals, []string{`0`, `1`, `2`, `3`})
+		gtest.Equal(errs, []error{nil, nil, nil, nil})
+		gtest.Len(ctxs, 4)
+		gtest.ErrIs(ctxs[0].Err(), context.Canceled, `derived context must be canceled when done`)
+	})
+
+	t.Run(`failure_cancels`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var started atomic.Int64
+		vals, errs := gg.ConcMapCtxCatch(nil, gg.ConcLim{Max: 2}, gg.Span(100), func(ctx context.Context, val int) int {
+			started.Add(1)
+			if val == 1 {
+				panic(testErrUntracedA)
+			}
+			if val == 0 {
+				<-ctx.Done()
+			}
+			return val
+		})
+
+		gtest.Len(vals, 100)
+		gtest.Len(errs, 100)
+		gtest.Zero(errs[0])
+		testWrappedErr(errs[1], testErrUntracedA)
+
+		skipped := gg.Count(errs, func(err error) bool { return e.Is(err, context.Canceled) })
+		gtest.Eq(skipped+int(started.Load()), 100)
+		gtest.LessPrim(0, skipped)
+
+		for ind, err := range errs[2:] {
+			if err != nil {
+				gtest.ErrStr(`skipped due to an earlier failure`, err)
+				gtest.Zero(vals[ind+2])
+			}
+		}
+	})
+
+	t.Run(`parent_canceled`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := gg.ConcEachCtxCatch(ctx, gg.ConcLim{Max: 1}, gg.Span(10), func(_ context.Context, val int) {
+			if val == 2 {
+				cancel()
+			}
+		})
+
+		gtest.Equal(errs[:3], []error{nil, nil, nil})
+		for _, err := range errs[3:] {
+			gtest.ErrIs(err, context.Canceled)
+			gtest.ErrStr(`skipped because the context is done`, err)
+		}
+	})
+
+	t.Run(`parent_deadline`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 0)
+		defer cancel()
+
+		errs := gg.ConcEachCtxCatch(ctx, gg.ConcLim{}, gg.Span(3), func(context.Context, int) {
+			panic(`unreachable`)
+		})
+
+		gtest.Len(errs, 3)
+		for _, err := range errs {
+			gtest.ErrIs(err, context.DeadlineExceeded)
+		}
+	})
+}
+
+func TestConcEachCtx(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gg.ConcEachCtx(nil, gg.ConcLim{}, gg.Span(8), func(ctx context.Context, _ int) {
+		gtest.NoErr(ctx.Err())
+	})
+
+	testWrappedErr(
+		gg.Catch(func() {
+			gg.ConcEachCtx(nil, gg.ConcLim{Max: 1}, gg.Span(8), func(_ context.Context, val int) {
+				if val == 1 {
+					panic(testErrUntracedA)
+				}
+			})
+		}),
+		testErrUntracedA,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := gg.Catch(func() {
+		gg.ConcMapCtx(ctx, gg.ConcLim{}, gg.Span(8), func(context.Context, int) int { return 0 })
+	})
+	gtest.ErrIs(err, context.Canceled)
+	gtest.True(gg.IsErrTraced(err))
+}
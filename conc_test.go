@@ -4,7 +4,9 @@ import (
 	"context"
 	e "errors"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/mitranim/gg"
 	"github.com/mitranim/gg/grepr"
@@ -162,6 +164,32 @@ func testWrappedErrs(act, exp []error, opt ...any) {
 	}
 }
 
+/*
+Tracks the amount of concurrently running calls and its peak. Used for testing
+concurrency limits.
+*/
+type testConcPeak struct {
+	running atomic.Int64
+	peak    atomic.Int64
+}
+
+/*
+Counts the current call as running for the given duration, updating the peak
+amount of concurrently running calls.
+*/
+func (self *testConcPeak) Run(dur time.Duration) {
+	cur := self.running.Add(1)
+	defer self.running.Add(-1)
+
+	for {
+		prev := self.peak.Load()
+		if cur <= prev || self.peak.CompareAndSwap(prev, cur) {
+			break
+		}
+	}
+	time.Sleep(dur)
+}
+
 func BenchmarkConcCatch_one(b *testing.B) {
 	for ind := 0; ind < b.N; ind++ {
 		_ = gg.ConcCatch(testPanicTraced0)
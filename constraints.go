@@ -194,6 +194,18 @@ errors in "github.com/pkg/errors".
 */
 type StackTraced interface{ StackTrace() []uintptr }
 
+/*
+Implemented by the `Err` type. Used by `ErrAttrGot`, `ErrAttrsDeep` and
+`Errs.Attrs` to retrieve error attributes from arbitrary error types.
+*/
+type ErrAttrer interface{ OwnAttrs() ErrAttrs }
+
+/*
+Implemented by the types `Err` and `ErrCode`. Used by `ErrCodeOf` and
+`ErrCode.Match` to retrieve error codes from arbitrary error types.
+*/
+type ErrCoder interface{ OwnCode() ErrCode }
+
 /*
 The method `.Init` must modify the receiver, initializing any components that
 need initialization, for example using `make` to create inner maps or chans.
@@ -242,6 +254,27 @@ a lifetime for other values. Used by `Mem`.
 */
 type Durationer interface{ Duration() time.Duration }
 
+/*
+Optional extension of `Durationer` which enables stale-while-revalidate in
+`Mem`. The hard duration is the maximum age of data which may be served while
+it's being refreshed. See `Mem.Get` and `DurStale`.
+*/
+type HardDurationer interface {
+	Durationer
+	HardDuration() time.Duration
+}
+
+/*
+Optional extension of `Durationer` which enables recording of failures in
+`Mem`. The error duration determines how long a failure is remembered, before
+initialization is retried. Unlike other durations, 0 or negative means that
+failures are shared only with concurrent callers. See `Mem.Get` and `DurErr`.
+*/
+type ErrDurationer interface {
+	Durationer
+	ErrDuration() time.Duration
+}
+
 /*
 Implemented by various types such as `context.Context`, `sql.Rows`, and our own
 `Errs`.
@@ -27,6 +27,8 @@ Represents a dynamically-scoped variable. Building block for goroutine-local
 storage (GLS), similar to thread-local storage (TLS) but adapted for Go.
 
 Uses [GidFunc], if set, to determine the GID, falling back on [Gid].
+Alternatively, values may be scoped to call subtrees rather than goroutines,
+see [DynBackend].
 
 The authors of Go have always objected to exposing goroutine IDs or providing
 any form of GLS, but Go itself uses TLS internally, and for testing they import
@@ -54,11 +56,15 @@ parent to the child goroutine via [GlsSnap] and [GlsSet].
 
 Performance: on architectures and in Go versions supported by the "fast path"
 of [Gid], [DynVar] operations are fairly cheap. The overhead of [DynVar.Get]
-is mostly one [sync.Map.Load]; in Go 1.24 on M3 Pro, it clocks at around 10ns
-in a single-goroutine benchmark, though costs may vary under contention.
-[DynVar.Set] is similar, but also involves a conversion of the input to `any`
-which makes a heap copy of any value wider than a machine word; very large
-objects should be passed by pointer, just like everywhere else in Go.
+is mostly one [sync.Map.Load] in a shard of the GLS registry, followed by a
+binary search among the current goroutine's variables; in Go 1.24 on M3 Pro,
+it clocks at around 10ns in a single-goroutine benchmark. The registry is
+sharded by goroutine ID, which reduces contention between goroutines.
+[DynVar.Set] is similar, but replaces the goroutine's immutable GLS with a
+modified copy, and involves a conversion of the input to `any` which makes a
+heap copy of any value wider than a machine word; very large objects should be
+passed by pointer, just like everywhere else in Go. In exchange, propagating
+GLS to child goroutines via [GlsGo] doesn't copy it.
 
 A zero value is ready to use. [DynVar] contains a synchronization primitive
 and must not be copied after first use.
@@ -73,10 +79,11 @@ propagate its GLS to child goroutines.
 See [GlsGo] for usage examples.
 */
 type DynVar[A any] struct {
-	lock sync.Mutex
-	has  atomic.Bool
-	def  func() A
-	val  A
+	lock    sync.Mutex
+	has     atomic.Bool
+	def     func() A
+	val     A
+	backend DynBackend
 }
 
 /*
@@ -89,12 +96,11 @@ returns that value. Otherwise:
   - Otherwise: returns the zero value.
 */
 func (self *DynVar[A]) Get() A {
-	gid := getGid()
-	val, ok := self.got(gid)
+	val, ok := self.got()
 	if ok {
 		return val
 	}
-	return self.getDef(gid)
+	return self.getDef()
 }
 
 /*
@@ -103,7 +109,7 @@ goroutine, or the zero value, and a boolean indicating if the value was set.
 Does _not_ fall back on the default value, even if the default function was
 provided in [NewDynVar].
 */
-func (self *DynVar[A]) Got() (A, bool) { return self.got(getGid()) }
+func (self *DynVar[A]) Got() (A, bool) { return self.got() }
 
 /*
 Returns the current value of this dynamic variable, if set on the current
@@ -122,16 +128,20 @@ func (self *DynVar[A]) GetOr(fun func() A) A {
 		return self.Get()
 	}
 
-	gid := getGid()
-	gls := glss.getOrMake(gid)
-	key := self.key()
-	val, ok := gls[key].(A)
+	val, ok := self.got()
 	if ok {
 		return val
 	}
 
 	val = fun()
-	gls[key] = val
+	if self.isSid() {
+		self.With(val).Use()
+		return val
+	}
+
+	// The function may modify the GLS, so we must get it again.
+	gid := getGid()
+	glss.set(gid, glss.get(gid).with(self.key(), val))
 	return val
 }
 
@@ -161,7 +171,7 @@ when using [DynVar.GetOr]. In such cases, user code must ensure either Option 1
 or Option 2.
 */
 func (self *DynVar[A]) Set(val A) GlsVal {
-	return GlsVal{key: self.key(), val: val}.Use()
+	return self.With(val).Use()
 }
 
 /*
@@ -173,7 +183,7 @@ goroutine, and should be chained into deferred [GlsVal.Use] to restore the
 entry on completion. See the comment on [DynVar.Set] for additional info.
 */
 func (self *DynVar[A]) Clear() GlsVal {
-	return GlsVal{key: self.key(), del: true}.Use()
+	return self.WithClear().Use()
 }
 
 /*
@@ -182,7 +192,7 @@ value on the goroutine where it's invoked. Eligible for any goroutine. Can be
 passed as an override when calling [GlsGo], [GlsGo1], [GlsRun], [GlsRun1].
 */
 func (self *DynVar[A]) With(val A) GlsVal {
-	return GlsVal{key: self.key(), val: val}
+	return GlsVal{key: self.key(), val: val, sid: self.isSid()}
 }
 
 /*
@@ -191,27 +201,37 @@ goroutine where it's invoked. Eligible for any goroutine. Can be passed
 as an override when calling [GlsGo], [GlsGo1], [GlsRun], [GlsRun1].
 */
 func (self *DynVar[A]) WithClear() GlsVal {
-	return GlsVal{key: self.key(), del: true}
+	return GlsVal{key: self.key(), del: true, sid: self.isSid()}
 }
 
 /* Internal */
 
 func (self *DynVar[A]) key() glsKey { return glsKey(unsafe.Pointer(self)) }
 
-func (self *DynVar[A]) got(gid uint64) (A, bool) {
-	src := glss.get(gid)[self.key()]
+func (self *DynVar[A]) isSid() bool { return self.backend.get() == DynBackendSid }
+
+// Returns the storage of the current goroutine or SID scope.
+func (self *DynVar[A]) load() *gls {
+	if self.isSid() {
+		return sidLoad()
+	}
+	return glss.get(getGid())
+}
+
+func (self *DynVar[A]) got() (A, bool) {
+	src, _ := self.load().get(self.key())
 	val, ok := src.(A)
 	return val, ok
 }
 
-func (self *DynVar[A]) getDef(gid uint64) (_ A) {
+func (self *DynVar[A]) getDef() (_ A) {
 	if self.has.Load() {
 		return self.val
 	}
-	return self.initDef(gid)
+	return self.initDef()
 }
 
-func (self *DynVar[A]) initDef(gid uint64) A {
+func (self *DynVar[A]) initDef() A {
 	defer Lock(&self.lock).Unlock()
 
 	if self.has.Load() {
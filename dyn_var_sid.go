@@ -0,0 +1,260 @@
+package gg
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+/*
+This file implements the SID backend of dynamic variables ([DynVar]), where
+values are scoped to a call subtree identified by a stack ID ([Sid]), rather
+than to a goroutine identified by a goroutine ID ([Gid]).
+*/
+
+/*
+Determines where a [DynVar] stores its values. Selected per variable via
+[NewDynVarBackend], or globally via [DynBackendDefault].
+*/
+type DynBackend byte
+
+const (
+	/**
+	Uses [DynBackendDefault]. This is the backend of variables created via
+	[NewDynVar], and of zero-value variables.
+	*/
+	DynBackendGlobal DynBackend = iota
+
+	/**
+	Values are stored per goroutine, keyed by [Gid]. Values set via
+	[DynVar.Set] are visible on the current goroutine until restored or
+	cleared, and must be cleaned up, see [DynVar.Set].
+	*/
+	DynBackendGid
+
+	/**
+	Values are stored per call subtree, keyed by [Sid]. Values are bound via
+	[GlsRun] or [GlsRun1] with overrides created by [DynVar.With], are visible
+	only within the call, and need no cleanup. See [DynBackendDefault] for
+	details.
+	*/
+	DynBackendSid
+)
+
+/*
+Backend used by dynamic variables created with [DynBackendGlobal], which
+includes variables created via [NewDynVar] and zero-value variables. If unset,
+[DynBackendGid] is used. Like [GidFunc], this should be set once, on startup,
+before using any dynamic variables: changing the backend makes previously-set
+values invisible.
+
+The SID backend ([DynBackendSid]) works as follows. [GlsRun] and [GlsRun1]
+with overrides for SID-backed variables run the given function in a new scope
+which encodes a unique ID into the call stack, see [WithSid]. The scope stores
+an immutable snapshot of the values: the values of the enclosing scope, if any,
+with the overrides. Every access to a SID-backed variable walks the call stack
+to find the innermost scope. When the call returns, the scope and its values are
+deleted, so unlike the default backend, there's nothing to clean up and nothing
+to leak. Example:
+
+	var REQ_ID = gg.NewDynVarBackend[string](gg.DynBackendSid, nil)
+
+	func handle(id string) {
+		gg.GlsRun(process, REQ_ID.With(id))
+		// Here, `REQ_ID` is no longer set.
+	}
+
+	func process() { _ = REQ_ID.Get() }
+
+Within a scope, [DynVar.Set] and [DynVar.Clear] modify that scope until it
+ends; the returned [GlsVal] may still be used to restore the previous value
+earlier. Outside of any scope, [DynVar.Set] and [DynVar.GetOr] panic, because
+there's nowhere to store the value.
+
+Scopes are inherited by goroutines spawned via [GlsGo], [GlsGo1], [GlsGroup],
+[GlsPool], which run in their own scopes holding the same values. Goroutines
+spawned with the plain `go` keyword don't inherit scopes. Functions which
+operate on the GLS of a goroutine as a whole, such as [GlsSnap], [GlsSet],
+[GlsBind], [GlsSnapCtx], [GlsDump], ignore SID-backed variables.
+
+Performance: finding the current scope requires walking the call stack via
+[runtime.Callers], which is much slower than the "fast path" of [Gid], and gets
+slower with deeper stacks, but doesn't depend on Go internals. On platforms
+where [Gid] is unsupported, the SID backend is much faster than the fallback
+implementation of [Gid]. Once any SID scope has been created, [GlsGo] and
+similar functions also walk the call stack, to propagate the current scope.
+See `BenchmarkDynVar_*_sid` in tests.
+*/
+var DynBackendDefault = DynBackendGid
+
+/*
+Like [NewDynVar], but with the given backend. See [DynBackend] and
+[DynBackendDefault].
+*/
+func NewDynVarBackend[A any](backend DynBackend, def func() A) *DynVar[A] {
+	return &DynVar[A]{def: def, backend: backend}
+}
+
+/*
+Returns the backend used by this variable: either [DynBackendGid] or
+[DynBackendSid]. Never returns [DynBackendGlobal].
+*/
+func (self *DynVar[A]) Backend() DynBackend { return self.backend.get() }
+
+func (self DynBackend) get() DynBackend {
+	if self == DynBackendGlobal {
+		self = DynBackendDefault
+	}
+	if self == DynBackendSid {
+		return DynBackendSid
+	}
+	return DynBackendGid
+}
+
+/* Internal */
+
+/*
+Storage of SID scopes created by `sidRun`, keyed by SID. Reuses the GLS
+registry, since scopes have the same lifecycle as goroutine GLS: each scope is
+created, modified, and deleted by the goroutine executing it.
+*/
+var sidGlss glss_t
+
+/*
+Set on the first use of the SID backend. Allows the GLS propagation in
+`glsCopy` to skip walking the call stack in programs which don't use it.
+*/
+var sidUsed atomic.Bool
+
+/*
+Runs the given function in a new SID scope with the given values. The scope's
+SID is unique among concurrent scopes, see [WithSid]. Empty scopes are valid,
+and hide the values of enclosing scopes.
+*/
+func sidRun(val *gls, fun func()) {
+	sidUsed.Store(true)
+
+	sid := sidGet()
+	defer sidFree(sid)
+
+	sidGlss.set(sid, val)
+	defer sidGlss.del(sid)
+
+	sid_scope(sid, fun)
+}
+
+/*
+Returns the SID and values of the innermost scope created by `sidRun`, if any.
+Similar to [Sid], but skips SIDs encoded by [WithSid] and [WithGivenSid] in user
+code, which may shadow our scopes. Our scopes are identified by `sid_scope`
+directly above `sid_end` in the stack.
+*/
+func sidScope() (_ uint64, _ *gls, _ bool) {
+	if !sidUsed.Load() {
+		return
+	}
+
+	buf := make([]uintptr, 64)
+
+	var sid uint64
+	var end bool
+
+	for _, addr := range sidCallers(buf, 2) {
+		if addr == 0 {
+			return
+		}
+
+		if end {
+			if addr == sidScopePc {
+				return sid, sidGlss.get(sid), true
+			}
+			sid, end = 0, false
+		}
+
+		if addr == sidEndPc {
+			end = true
+			continue
+		}
+
+		dig, ok := sidPcsToDigits[addr]
+		if ok {
+			sid = sid<<sidDigitBits | uint64(dig)
+		}
+	}
+	return
+}
+
+// Returns the values of the innermost SID scope, if any.
+func sidLoad() *gls {
+	_, out, _ := sidScope()
+	return out
+}
+
+/*
+If any of the given entries belong to SID-backed variables, returns the values
+of the innermost SID scope with those entries applied, and true.
+*/
+func sidWith(vals []GlsVal) (*gls, bool) {
+	var out *gls
+	var ok bool
+
+	for _, val := range vals {
+		if !val.sid {
+			continue
+		}
+		if !ok {
+			out, ok = sidLoad(), true
+		}
+		out = out.use(val)
+	}
+	return out, ok
+}
+
+/*
+SYNC[gls_val_use]. Outside of SID scopes, entries which clear a variable are
+no-ops, while entries which set a variable panic.
+*/
+func (self GlsVal) useSid() GlsVal {
+	sid, gls, ok := sidScope()
+	prev := gls.prev(self.key)
+	prev.sid = true
+
+	if !ok {
+		if self.del {
+			return prev
+		}
+		panic(Errv(`unable to set SID-backed dynamic variable outside of a SID scope; bind it via GlsRun`))
+	}
+
+	if !self.del || !prev.del {
+		sidGlss.set(sid, gls.use(self))
+	}
+	return prev
+}
+
+var sidScopePc = sidScopeToPc()
+
+func sidScopeToPc() (out uintptr) {
+	sid_scope(0, func() {
+		buf := make([]uintptr, 1)
+
+		/**
+		Skip 4 PCs:
+
+		- `runtime.Callers`
+		- this closure
+		- `sid_start`
+		- `sid_end`
+
+		This gets us the PC of `sid_scope`.
+		*/
+		out = buf[:runtime.Callers(4, buf)][0]
+	})
+
+	if out == 0 {
+		panic(Errf(`internal error: unable to determine program counter for function %v`, sid_scope))
+	}
+	return
+}
+
+//go:noinline
+func sid_scope(sid uint64, fun func()) { sid_end(sid, fun) }
@@ -0,0 +1,299 @@
+package gg_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+var SID_NUM = gg.NewDynVarBackend[int](gg.DynBackendSid, nil)
+var SID_STR = gg.NewDynVarBackend[string](gg.DynBackendSid, func() string { return `def` })
+
+func TestDynVar_Backend(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Eq(DYN_NUM.Backend(), gg.DynBackendGid)
+	gtest.Eq(SID_NUM.Backend(), gg.DynBackendSid)
+	gtest.Eq(gg.NewDynVarBackend[int](gg.DynBackendGid, nil).Backend(), gg.DynBackendGid)
+
+	var dyn gg.DynVar[int]
+	gtest.Eq(dyn.Backend(), gg.DynBackendGid)
+
+	defer gg.SnapSwap(&gg.DynBackendDefault, gg.DynBackendSid).Done()
+	gtest.Eq(dyn.Backend(), gg.DynBackendSid)
+	gtest.Eq(DYN_NUM.Backend(), gg.DynBackendSid)
+	gtest.Eq(gg.NewDynVarBackend[int](gg.DynBackendGid, nil).Backend(), gg.DynBackendGid)
+
+	gg.GlsRun(func() {
+		gtest.Eq(dyn.Get(), 10)
+		gtest.Len(gg.GlsSnap(), 0)
+	}, dyn.With(10))
+
+	gtest.Zero(dyn.Get())
+}
+
+func TestDynVar_sid(t *testing.T) {
+	defer gtest.Catch(t)
+	defer gtest.GlsNoLeaks(t)
+
+	gtest.Zero(SID_NUM.Get())
+	gtest.Eq(SID_STR.Get(), `def`)
+
+	var called Called
+
+	gg.GlsRun(func() {
+		gtest.Eq(SID_NUM.Get(), 10)
+		gtest.Eq(SID_STR.Get(), `def`)
+		gtest.Eq(deepGet(SID_NUM, 256), 10, `must find the scope in deep stacks`)
+
+		gg.GlsRun(func() {
+			gtest.Eq(SID_NUM.Get(), 20)
+			gtest.Eq(SID_STR.Get(), `one`)
+
+			gg.GlsRun(func() {
+				gtest.Eq(SID_NUM.Get(), 20)
+				gtest.Eq(SID_STR.Get(), `def`, `clearing must hide the enclosing scope`)
+
+				val, ok := SID_STR.Got()
+				gtest.Zero(val)
+				gtest.False(ok)
+			}, SID_STR.WithClear())
+
+			called.Here()
+		}, SID_NUM.With(20), SID_STR.With(`one`))
+
+		called.Verify()
+		gtest.Eq(SID_NUM.Get(), 10)
+		gtest.Eq(SID_STR.Get(), `def`)
+	}, SID_NUM.With(10))
+
+	gtest.Zero(SID_NUM.Get())
+	gtest.Zero(gg.Glss(), `must not touch goroutine storage`)
+}
+
+func TestDynVar_sid_shadowed(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gg.GlsRun(func() {
+		gg.WithSid(func() {
+			gtest.Eq(SID_NUM.Get(), 10)
+
+			gg.WithGivenSid(1, func() {
+				gtest.Eq(SID_NUM.Get(), 10)
+			})
+		})
+	}, SID_NUM.With(10))
+}
+
+func TestDynVar_sid_Set(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.PanicStr(`outside of a SID scope`, func() { SID_NUM.Set(10) })
+	gtest.PanicStr(`outside of a SID scope`, func() { SID_NUM.GetOr(func() int { return 10 }) })
+	SID_NUM.Clear().Use()
+	gtest.Zero(SID_NUM.Get())
+
+	gg.GlsRun(func() {
+		gtest.Eq(SID_NUM.Get(), 10)
+
+		prev := SID_NUM.Set(20)
+		gtest.Eq(SID_NUM.Get(), 20)
+
+		prev.Use()
+		gtest.Eq(SID_NUM.Get(), 10)
+
+		SID_NUM.Clear()
+		gtest.Zero(SID_NUM.Get())
+
+		gtest.Eq(SID_NUM.GetOr(func() int { return 30 }), 30)
+		gtest.Eq(SID_NUM.GetOr(func() int { return 40 }), 30)
+
+		gg.GlsRun(func() {
+			SID_NUM.Set(50)
+			gtest.Eq(SID_NUM.Get(), 50)
+		}, SID_STR.With(`one`))
+
+		gtest.Eq(SID_NUM.Get(), 30, `modifications must be scoped`)
+		SID_NUM.Set(60)
+	}, SID_NUM.With(10))
+
+	gtest.Zero(SID_NUM.Get(), `no cleanup required`)
+}
+
+func TestDynVar_sid_mixed(t *testing.T) {
+	defer gtest.Catch(t)
+	defer gtest.GlsNoLeaks(t)
+
+	gg.GlsRun(func() {
+		gtest.Eq(SID_NUM.Get(), 10)
+		gtest.Eq(DYN_NUM.Get(), 20)
+
+		snap := gg.GlsSnap(SID_STR.With(`one`))
+		gtest.Len(snap, 1)
+
+		gg.GlsRun1(func(val string) {
+			gtest.Eq(val, `two`)
+			gtest.Eq(SID_NUM.Get(), 10)
+			gtest.Eq(DYN_NUM.Get(), 30)
+			gtest.Eq(SID_STR.Get(), `three`)
+		}, `two`, DYN_NUM.With(30), SID_STR.With(`three`))
+
+		gtest.Eq(DYN_NUM.Get(), 20)
+		gtest.Eq(SID_STR.Get(), `def`)
+	}, SID_NUM.With(10), DYN_NUM.With(20))
+
+	gtest.Zero(SID_NUM.Get())
+	gtest.Zero(DYN_NUM.Get())
+}
+
+func TestDynVar_sid_goroutines(t *testing.T) {
+	defer gtest.Catch(t)
+	defer gtest.GlsNoLeaks(t)
+
+	gg.GlsRun(func() {
+		gtest.Eq(goWait(SID_NUM.Get), 0, `plain goroutines must not inherit`)
+
+		out := make(chan [2]int, 2)
+		get := func() { out <- [2]int{SID_NUM.Get(), DYN_NUM.Get()} }
+
+		gg.GlsGo(get)
+		gtest.Eq(<-out, [2]int{10, 0})
+
+		gg.GlsGo(get, SID_NUM.With(20), DYN_NUM.With(30))
+		gtest.Eq(<-out, [2]int{20, 30})
+
+		gg.GlsGo(get, SID_NUM.WithClear())
+		gtest.Eq(<-out, [2]int{0, 0})
+
+		gg.GlsGo1(func(val int) { out <- [2]int{SID_NUM.Get(), val} }, 40)
+		gtest.Eq(<-out, [2]int{10, 40})
+
+		group := gg.NewGlsGroup(nil, 0)
+		group.Go(func(context.Context) { get() })
+		group.Go(func(context.Context) { get() }, SID_NUM.With(50))
+		gtest.NoErr(group.Wait())
+		gtest.Eq(gg.Sum([]int{(<-out)[0], (<-out)[0]}), 60)
+
+		pool := gg.NewGlsPool(nil, 1)
+		pool.Go(func(context.Context) { get() }, SID_NUM.With(60))
+		gtest.NoErr(pool.Wait())
+		gtest.Eq(<-out, [2]int{60, 0})
+	}, SID_NUM.With(10))
+}
+
+func deepGet[A any](dyn *gg.DynVar[A], depth int) A {
+	if depth > 0 {
+		return deepGet(dyn, depth-1)
+	}
+	return dyn.Get()
+}
+
+func BenchmarkDynVar_Get_num_gid(b *testing.B) {
+	defer gtest.Catch(b)
+	defer DYN_NUM.Set(123).Use()
+
+	for ind := 0; ind < b.N; ind++ {
+		gg.Nop1(DYN_NUM.Get())
+	}
+}
+
+func BenchmarkDynVar_Get_num_sid(b *testing.B) {
+	defer gtest.Catch(b)
+
+	gg.GlsRun(func() {
+		for ind := 0; ind < b.N; ind++ {
+			gg.Nop1(SID_NUM.Get())
+		}
+	}, SID_NUM.With(123))
+}
+
+func BenchmarkDynVar_Get_num_deep_gid(b *testing.B) {
+	defer gtest.Catch(b)
+	defer DYN_NUM.Set(123).Use()
+
+	for ind := 0; ind < b.N; ind++ {
+		gg.Nop1(deepGet(DYN_NUM, 64))
+	}
+}
+
+func BenchmarkDynVar_Get_num_deep_sid(b *testing.B) {
+	defer gtest.Catch(b)
+
+	gg.GlsRun(func() {
+		for ind := 0; ind < b.N; ind++ {
+			gg.Nop1(deepGet(SID_NUM, 64))
+		}
+	}, SID_NUM.With(123))
+}
+
+func BenchmarkDynVar_Get_num_empty_sid(b *testing.B) {
+	defer gtest.Catch(b)
+
+	for ind := 0; ind < b.N; ind++ {
+		gg.Nop1(SID_NUM.Get())
+	}
+}
+
+func BenchmarkDynVar_bind_gid(b *testing.B) {
+	defer gtest.Catch(b)
+
+	for ind := 0; ind < b.N; ind++ {
+		gg.GlsRun1(benchGetNum, DYN_NUM, DYN_NUM.With(ind))
+	}
+}
+
+func BenchmarkDynVar_bind_sid(b *testing.B) {
+	defer gtest.Catch(b)
+
+	for ind := 0; ind < b.N; ind++ {
+		gg.GlsRun1(benchGetNum, SID_NUM, SID_NUM.With(ind))
+	}
+}
+
+func BenchmarkDynVar_Set_num_gid(b *testing.B) {
+	defer gtest.Catch(b)
+	b.Cleanup(gg.GlsClear)
+
+	for ind := 0; ind < b.N; ind++ {
+		DYN_NUM.Set(ind)
+	}
+}
+
+func BenchmarkDynVar_Set_num_sid(b *testing.B) {
+	defer gtest.Catch(b)
+
+	gg.GlsRun(func() {
+		for ind := 0; ind < b.N; ind++ {
+			SID_NUM.Set(ind)
+		}
+	}, SID_NUM.With(0))
+}
+
+func BenchmarkDynVar_Get_parallel_gid(b *testing.B) {
+	defer gtest.Catch(b)
+
+	b.RunParallel(func(pb *testing.PB) {
+		defer gg.GlsClear()
+		DYN_NUM.Set(123)
+
+		for pb.Next() {
+			gg.Nop1(DYN_NUM.Get())
+		}
+	})
+}
+
+func BenchmarkDynVar_Get_parallel_sid(b *testing.B) {
+	defer gtest.Catch(b)
+
+	b.RunParallel(func(pb *testing.PB) {
+		gg.GlsRun(func() {
+			for pb.Next() {
+				gg.Nop1(SID_NUM.Get())
+			}
+		}, SID_NUM.With(123))
+	})
+}
+
+func benchGetNum(dyn *gg.DynVar[int]) { gg.Nop1(dyn.Get()) }
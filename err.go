@@ -19,7 +19,9 @@ error wrapping. Provides a convenient builder API.
 type Err struct {
 	Msg   string
 	Cause error
-	Trace *Trace // By pointer to allow `==` without panics.
+	Code  ErrCode
+	Trace *Trace    // By pointer to allow `==` without panics.
+	Attrs *ErrAttrs // By pointer to allow `==` without panics.
 }
 
 // Implement `error`.
@@ -28,15 +30,37 @@ func (self Err) Error() string { return self.String() }
 // Implement a hidden interface for compatibility with `"errors".Unwrap`.
 func (self Err) Unwrap() error { return self.Cause }
 
-// Implement a hidden interface for compatibility with `"errors".Is`.
+/*
+Implement a hidden interface for compatibility with `"errors".Is`. When the
+target is a non-empty `ErrCode`, also compares it with `.Code`. An error
+without a code never matches an empty code.
+*/
 func (self Err) Is(err error) bool {
-	val, ok := err.(Err)
-	if ok {
+	switch val := err.(type) {
+	case Err:
 		return self.Msg == val.Msg && errors.Is(self.Cause, val.Cause)
+	case ErrCode:
+		if val != `` && self.Code == val {
+			return true
+		}
 	}
 	return errors.Is(self.Cause, err)
 }
 
+/*
+Implement a hidden interface for compatibility with `"errors".As`. When the
+target is `*ErrCode` and `.Code` is non-empty, assigns `.Code` to the target.
+This allows `ErrAs[ErrCode]` to find the nearest code.
+*/
+func (self Err) As(out any) bool {
+	ptr, _ := out.(*ErrCode)
+	if ptr != nil && self.Code != `` {
+		*ptr = self.Code
+		return true
+	}
+	return false
+}
+
 /*
 Implement `Errer`. If the receiver is a zero value, returns nil. Otherwise casts
 the receiver to an error.
@@ -116,7 +140,7 @@ func (self Err) appendStackWithCauseWithoutMsg(buf Buf) Buf {
 	cause := self.Cause
 	trace := self.OwnTrace()
 
-	if trace.IsEmpty() {
+	if trace.IsEmpty() && !self.isTraceShared() {
 		buf.AppendErrorStack(cause)
 		return buf
 	}
@@ -128,8 +152,11 @@ func (self Err) appendStackWithCauseWithoutMsg(buf Buf) Buf {
 		return errAppendTraceIndentWithNewline(buf, trace)
 	}
 
+	size := len(buf)
 	buf = errAppendTraceIndent(buf, trace)
-	buf.AppendNewline()
+	if len(buf) > size {
+		buf.AppendNewline()
+	}
 	buf.AppendErrorStack(cause)
 	return buf
 }
@@ -138,7 +165,7 @@ func (self Err) appendStackWithCauseWithMsg(buf Buf) Buf {
 	cause := self.Cause
 	trace := self.OwnTrace()
 
-	if trace.IsEmpty() {
+	if trace.IsEmpty() && !self.isTraceShared() {
 		buf.AppendString(self.Msg)
 		buf.AppendString(`: `)
 		buf.AppendErrorStack(cause)
@@ -159,9 +186,17 @@ func (self Err) appendStackWithCauseWithMsg(buf Buf) Buf {
 	return buf
 }
 
-// Implement `fmt.Formatter`.
+/*
+Implement `fmt.Formatter`. The flag "+" prints the stack, like `Err.Stack`.
+The flags "+#" print the stack followed by source snippets, like
+`ErrStackSnippet`.
+*/
 func (self Err) Format(out fmt.State, verb rune) {
 	if out.Flag('+') {
+		if out.Flag('#') {
+			_, _ = out.Write(errAppendStackSnippet(nil, self))
+			return
+		}
 		_, _ = out.Write(self.AppendStackTo(nil))
 		return
 	}
@@ -178,6 +213,13 @@ func (self Err) Format(out fmt.State, verb rune) {
 // Safely dereferences `.Trace`, returning nil if the pointer is nil.
 func (self Err) OwnTrace() Trace { return PtrGet(self.Trace) }
 
+/*
+True if the entire trace was moved to the shared trace by `errGroupsTrimTrace`.
+In this case, the trace of the cause must not be printed in place of the
+omitted trace.
+*/
+func (self Err) isTraceShared() bool { return self.Trace == &errTraceShared }
+
 /*
 Implement `StackTraced`, which allows to retrieve stack traces from nested
 errors.
@@ -190,8 +232,13 @@ func (self Err) Msgd(val string) Err {
 	return self
 }
 
-// Returns a modified version where `.Msg` is set from `fmt.Sprintf`.
+/*
+Returns a modified version where `.Msg` is set from `fmt.Sprintf`. Arguments of
+type `ErrAttr` are excluded from formatting and appended to `.Attrs`. Arguments
+of type `ErrCode` are excluded from formatting and set as `.Code`.
+*/
 func (self Err) Msgf(pat string, arg ...any) Err {
+	self, arg = self.metad(arg)
 	self.Msg = fmt.Sprintf(pat, NoEscUnsafe(arg)...)
 	return self
 }
@@ -199,13 +246,51 @@ func (self Err) Msgf(pat string, arg ...any) Err {
 /*
 Returns a modified version where `.Msg` is set to a concatenation of strings
 generated from the arguments, via `Str`. See `StringCatch` for the encoding
-rules.
+rules. Arguments of type `ErrAttr` are excluded from the message and appended
+to `.Attrs`. Arguments of type `ErrCode` are excluded from the message and set
+as `.Code`.
 */
 func (self Err) Msgv(src ...any) Err {
+	self, src = self.metad(src)
 	self.Msg = Str(src...)
 	return self
 }
 
+/*
+Applies message arguments of type `ErrAttr` and `ErrCode` to the error,
+returning the remaining arguments. When there are no such arguments, returns
+the input as-is without allocating.
+*/
+func (self Err) metad(src []any) (Err, []any) {
+	if !Some(src, isErrMeta) {
+		return self, src
+	}
+
+	var msg []any
+	var attrs ErrAttrs
+
+	for _, val := range src {
+		switch val := val.(type) {
+		case ErrAttr:
+			attrs = append(attrs, val)
+		case ErrCode:
+			self.Code = val
+		default:
+			msg = append(msg, val)
+		}
+	}
+	return self.Attrd(attrs...), msg
+}
+
+func isErrMeta(val any) bool {
+	switch val.(type) {
+	case ErrAttr, ErrCode:
+		return true
+	default:
+		return false
+	}
+}
+
 // Returns a modified version with the given `.Cause`.
 func (self Err) Caused(val error) Err {
 	self.Cause = val
@@ -279,6 +364,7 @@ func (self Errs) As(out any) bool {
 /*
 Returns the first error that satisfies the given test function, by calling
 `ErrFind` on each element. Order is depth-first rather than breadth-first.
+Traverses the whole tree, including nested `Errs` and foreign multi-errors.
 */
 func (self Errs) Find(fun func(error) bool) error {
 	if fun != nil {
@@ -410,9 +496,13 @@ func (self Errs) append(buf Buf) Buf {
 	return buf
 }
 
-// Implement `fmt.Formatter`.
+// Implement `fmt.Formatter`. Supports the same flags as `Err.Format`.
 func (self Errs) Format(out fmt.State, verb rune) {
 	if out.Flag('+') {
+		if out.Flag('#') {
+			_, _ = out.Write(errAppendStackSnippet(nil, self))
+			return
+		}
 		_, _ = out.Write(self.AppendStackTo(nil))
 		return
 	}
@@ -426,7 +516,13 @@ func (self Errs) Format(out fmt.State, verb rune) {
 	_, _ = io.WriteString(out, self.Error())
 }
 
-// Appends a text representation of the errors with stack traces, if any.
+/*
+Appends a text representation of the errors with stack traces, if any. When
+there are multiple errors, errors with identical representations are printed
+once, preceded by their count, and the common suffix of their traces, if any,
+is printed once at the end. See `ErrGroup`. Nested multi-errors are printed as
+trees, see `ErrTreeStack`.
+*/
 func (self Errs) AppendStackTo(buf []byte) []byte {
 	err, count := self.find()
 
@@ -440,18 +536,7 @@ func (self Errs) AppendStackTo(buf []byte) []byte {
 		return buf
 
 	default:
-		buf := Buf(buf)
-		buf.AppendString(`multiple errors:`)
-
-		for _, val := range self {
-			if val == nil {
-				continue
-			}
-			buf.AppendNewlines(2)
-			buf.AppendErrorStack(val)
-		}
-
-		return buf
+		return errsAppendStackGrouped(buf, self)
 	}
 }
 
@@ -813,7 +898,9 @@ func ErrAs[
 Somewhat analogous to `errors.Is` and `errors.As`, but instead of comparing an
 error to another error value or checking its type, uses a predicate function.
 Uses `errors.Unwrap` to traverse the error chain and returns the outermost
-error that satisfies the predicate, or nil.
+error that satisfies the predicate, or nil. Multi-errors such as `Errs` and
+those which implement `Unwrap() []error`, such as from `errors.Join`, are
+traversed depth-first.
 */
 func ErrFind(err error, fun func(error) bool) error {
 	if fun == nil {
@@ -830,6 +917,11 @@ func ErrFind(err error, fun func(error) bool) error {
 			return err
 		}
 
+		multi, _ := err.(interface{ Unwrap() []error })
+		if multi != nil {
+			return Errs(multi.Unwrap()).Find(fun)
+		}
+
 		next := errors.Unwrap(err)
 		if ErrEq(next, err) {
 			break
@@ -0,0 +1,211 @@
+package gg
+
+import "errors"
+
+/*
+Shortcut for creating an `ErrAttr`. Usage:
+
+	gg.Wrap(err, `unable to load user `, userId, gg.ErrAttrOf(`user_id`, userId))
+	gg.Errf(`unable to read %q`, path).Attrd(gg.ErrAttrOf(`path`, path))
+*/
+func ErrAttrOf(key string, val any) ErrAttr { return ErrAttr{key, val} }
+
+/*
+Key-value attribute attached to an error, for machine-readable context such as
+user id, request id, or file path. Values are stored as-is, without being
+converted to strings, and can be retrieved with their original types via
+`ErrAttrGot` and `ErrAttrGet`.
+
+When passed to `Err.Msgv` or `Err.Msgf`, and therefore to `Errv`, `Errf`,
+`Wrap`, `Wrapf`, `Detail`, `Detailf`, values of this type are excluded from
+the message and attached to the error as attributes. When using `Errf`,
+`Wrapf`, `Detailf`, attributes must be placed after the formatting
+arguments.
+*/
+type ErrAttr struct {
+	Key string `json:"key"`
+	Val any    `json:"value"`
+}
+
+// Implement `fmt.Stringer`, returning "key=val".
+func (self ErrAttr) String() string { return AppenderString(self) }
+
+// Implement `AppenderTo`, appending the same representation as `.String`.
+func (self ErrAttr) AppendTo(inout []byte) []byte {
+	buf := Buf(inout)
+	buf.AppendString(self.Key)
+	buf.AppendString(`=`)
+	buf.AppendAny(self.Val)
+	return buf
+}
+
+/*
+Sequence of error attributes. Stored by `Err` and returned by `Err.OwnAttrs`,
+`Errs.Attrs`, `ErrAttrsDeep`. Keys are not required to be unique; lookups
+return the first matching entry, which corresponds to the outermost error.
+*/
+type ErrAttrs []ErrAttr
+
+/*
+Returns the value of the first attribute with the given key, and a boolean
+indicating if it was found.
+*/
+func (self ErrAttrs) Got(key string) (any, bool) {
+	for _, val := range self {
+		if val.Key == key {
+			return val.Val, true
+		}
+	}
+	return nil, false
+}
+
+/*
+Returns the value of the first attribute with the given key, or nil if not
+found.
+*/
+func (self ErrAttrs) Get(key string) any {
+	val, _ := self.Got(key)
+	return val
+}
+
+// True if there is at least one attribute with the given key.
+func (self ErrAttrs) Has(key string) bool {
+	_, ok := self.Got(key)
+	return ok
+}
+
+/*
+Converts the attributes to a map. When keys are repeated, the first occurrence
+wins, which corresponds to the outermost error.
+*/
+func (self ErrAttrs) Map() map[string]any {
+	if len(self) <= 0 {
+		return nil
+	}
+	out := make(map[string]any, len(self))
+	for _, val := range self {
+		_, ok := out[val.Key]
+		if !ok {
+			out[val.Key] = val.Val
+		}
+	}
+	return out
+}
+
+// Implement `fmt.Stringer`, returning space-separated "key=val" pairs.
+func (self ErrAttrs) String() string { return AppenderString(self) }
+
+// Implement `AppenderTo`, appending the same representation as `.String`.
+func (self ErrAttrs) AppendTo(inout []byte) []byte {
+	buf := Buf(inout)
+	for ind, val := range self {
+		if ind > 0 {
+			buf.AppendSpace()
+		}
+		buf = val.AppendTo(buf)
+	}
+	return buf
+}
+
+// Safely dereferences `.Attrs`, returning nil if the pointer is nil.
+func (self Err) OwnAttrs() ErrAttrs { return PtrGet(self.Attrs) }
+
+/*
+Returns a modified version with the given attributes appended to `.Attrs`.
+Does not mutate the attributes of the original.
+*/
+func (self Err) Attrd(src ...ErrAttr) Err {
+	if len(src) <= 0 {
+		return self
+	}
+	self.Attrs = Ptr(Concat(self.OwnAttrs(), src))
+	return self
+}
+
+/*
+Returns the attributes of every error in the tree, by calling `ErrAttrsDeep` on
+each element. Order is depth-first.
+*/
+func (self Errs) Attrs() (out ErrAttrs) {
+	for _, val := range self {
+		out = errAttrsAppend(out, val)
+	}
+	return
+}
+
+/*
+Returns the attributes of the given error and every error in its cause chain,
+outermost first. Unlike `errors.Unwrap`, this traverses every element of
+`Errs` rather than only the first one. Also see `ErrAttrGot` for looking up
+individual attributes.
+*/
+func ErrAttrsDeep(err error) ErrAttrs { return errAttrsAppend(nil, err) }
+
+/*
+Finds the nearest attribute with the given key whose value is of type `A`,
+walking the cause chain via `ErrFind`, which also descends into `Errs`.
+Returns the value and a boolean indicating if it was found. To retrieve a
+value of any type, use `ErrAttrGot[any]`.
+*/
+func ErrAttrGot[A any](err error, key string) (A, bool) {
+	var out A
+	var ok bool
+
+	ErrFind(err, func(err error) bool {
+		out, ok = errOwnAttrGot[A](err, key)
+		return ok
+	})
+	return out, ok
+}
+
+// Same as `ErrAttrGot` but returns only the value, or zero if not found.
+func ErrAttrGet[A any](err error, key string) A {
+	val, _ := ErrAttrGot[A](err, key)
+	return val
+}
+
+/*
+True if the given error or any error in its cause chain has an attribute with
+the given key, regardless of the value's type.
+*/
+func ErrAttrHas(err error, key string) bool {
+	_, ok := ErrAttrGot[any](err, key)
+	return ok
+}
+
+func errOwnAttrGot[A any](err error, key string) (_ A, _ bool) {
+	impl, _ := err.(ErrAttrer)
+	if impl == nil {
+		return
+	}
+	for _, val := range impl.OwnAttrs() {
+		if val.Key == key {
+			out, ok := val.Val.(A)
+			if ok {
+				return out, true
+			}
+		}
+	}
+	return
+}
+
+func errAttrsAppend(buf ErrAttrs, err error) ErrAttrs {
+	for err != nil {
+		impl, _ := err.(ErrAttrer)
+		if impl != nil {
+			buf = append(buf, impl.OwnAttrs()...)
+		}
+
+		errs, ok := err.(Errs)
+		if ok {
+			return append(buf, errs.Attrs()...)
+		}
+
+		next := errors.Unwrap(err)
+		if ErrEq(next, err) {
+			break
+		}
+		err = next
+	}
+	return buf
+}
@@ -0,0 +1,162 @@
+package gg_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+func TestErr_Attrd(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Zero(gg.Err{}.OwnAttrs())
+	gtest.Zero(gg.Err{}.Attrd().Attrs)
+
+	one := gg.Err{Msg: `one`}.Attrd(gg.ErrAttrOf(`key0`, 10))
+	two := one.Attrd(gg.ErrAttrOf(`key1`, `val1`))
+
+	gtest.Equal(one.OwnAttrs(), gg.ErrAttrs{{`key0`, 10}})
+	gtest.Equal(two.OwnAttrs(), gg.ErrAttrs{{`key0`, 10}, {`key1`, `val1`}})
+	gtest.Eq(two.Error(), `one`)
+	gtest.Eq(two.OwnAttrs().String(), `key0=10 key1=val1`)
+}
+
+func TestErr_Msgv_attrs(t *testing.T) {
+	defer gtest.Catch(t)
+
+	err := gg.Errv(`unable to load user `, 10, gg.ErrAttrOf(`user_id`, 10))
+
+	gtest.Eq(err.Msg, `unable to load user 10`)
+	gtest.Equal(err.OwnAttrs(), gg.ErrAttrs{{`user_id`, 10}})
+	gtest.True(err.IsTraced())
+}
+
+func TestErr_Msgf_attrs(t *testing.T) {
+	defer gtest.Catch(t)
+
+	err := gg.Errf(`unable to read %q`, `file.txt`, gg.ErrAttrOf(`path`, `file.txt`))
+
+	gtest.Eq(err.Msg, `unable to read "file.txt"`)
+	gtest.Equal(err.OwnAttrs(), gg.ErrAttrs{{`path`, `file.txt`}})
+}
+
+func TestWrap_attrs(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Zero(gg.Wrap(nil, gg.ErrAttrOf(`key`, `val`)))
+
+	err := gg.Wrap(io.EOF, `unable to read`, gg.ErrAttrOf(`key`, `val`)).(gg.Err)
+
+	gtest.Eq(err.Error(), `unable to read: EOF`)
+	gtest.Equal(err.OwnAttrs(), gg.ErrAttrs{{`key`, `val`}})
+	gtest.True(errors.Is(err, io.EOF))
+}
+
+func TestDetail_attrs(t *testing.T) {
+	defer gtest.Catch(t)
+
+	err := gg.Catch(func() {
+		defer gg.Detail(`unable to do X`, gg.ErrAttrOf(`req_id`, `abc`))
+		panic(io.EOF)
+	})
+
+	gtest.Eq(err.Error(), `unable to do X: EOF`)
+	gtest.Eq(gg.ErrAttrGet[string](err, `req_id`), `abc`)
+
+	err = gg.Catch(func() {
+		defer gg.Detailf(`unable to %v`, `do Y`, gg.ErrAttrOf(`req_id`, `def`))
+		panic(io.EOF)
+	})
+
+	gtest.Eq(err.Error(), `unable to do Y: EOF`)
+	gtest.Eq(gg.ErrAttrGet[string](err, `req_id`), `def`)
+}
+
+func TestErrAttrGot(t *testing.T) {
+	defer gtest.Catch(t)
+
+	test := func(err error, key string, expVal int, expOk bool) {
+		val, ok := gg.ErrAttrGot[int](err, key)
+		gtest.Eq(val, expVal)
+		gtest.Eq(ok, expOk)
+	}
+
+	test(nil, `key`, 0, false)
+	test(io.EOF, `key`, 0, false)
+	test(gg.Err{Msg: `one`}, `key`, 0, false)
+
+	inner := gg.Err{Msg: `inner`}.Attrd(gg.ErrAttrOf(`key`, 10), gg.ErrAttrOf(`inner`, 20))
+	outer := gg.Wrap(inner, `outer`, gg.ErrAttrOf(`key`, 30))
+	foreign := fmt.Errorf(`foreign: %w`, outer)
+
+	test(inner, `key`, 10, true)
+	test(inner, `inner`, 20, true)
+	test(outer, `key`, 30, true)
+	test(outer, `inner`, 20, true)
+	test(foreign, `key`, 30, true)
+	test(foreign, `inner`, 20, true)
+	test(foreign, `missing`, 0, false)
+
+	t.Run(`type_mismatch`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		err := gg.Wrap(inner, `outer`, gg.ErrAttrOf(`key`, `str`))
+
+		gtest.Eq(gg.ErrAttrGet[string](err, `key`), `str`)
+		gtest.Eq(gg.ErrAttrGet[int](err, `key`), 10)
+		gtest.Equal(gg.ErrAttrGet[any](err, `key`), any(`str`))
+		gtest.True(gg.ErrAttrHas(err, `key`))
+		gtest.False(gg.ErrAttrHas(err, `missing`))
+	})
+
+	t.Run(`Errs`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		errs := gg.Errs{
+			nil,
+			io.EOF,
+			gg.Err{Msg: `one`}.Attrd(gg.ErrAttrOf(`one`, 1)),
+			gg.Wrap(gg.Err{Msg: `two`}.Attrd(gg.ErrAttrOf(`two`, 2)), `wrap`),
+		}
+
+		test(errs, `one`, 1, true)
+		test(errs, `two`, 2, true)
+		test(gg.Wrap(errs, `outer`), `two`, 2, true)
+	})
+}
+
+func TestErrAttrsDeep(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Zero(gg.ErrAttrsDeep(nil))
+	gtest.Zero(gg.ErrAttrsDeep(io.EOF))
+
+	inner := gg.Err{Msg: `inner`}.Attrd(gg.ErrAttrOf(`key`, 10))
+	outer := gg.Wrap(inner, `outer`, gg.ErrAttrOf(`key`, 20))
+
+	gtest.Equal(
+		gg.ErrAttrsDeep(fmt.Errorf(`foreign: %w`, outer)),
+		gg.ErrAttrs{{`key`, 20}, {`key`, 10}},
+	)
+
+	errs := gg.Errs{
+		outer,
+		nil,
+		gg.Err{Msg: `other`}.Attrd(gg.ErrAttrOf(`other`, 30)),
+	}
+
+	exp := gg.ErrAttrs{{`key`, 20}, {`key`, 10}, {`other`, 30}}
+	gtest.Equal(errs.Attrs(), exp)
+	gtest.Equal(gg.ErrAttrsDeep(errs), exp)
+
+	gtest.Equal(
+		gg.ErrAttrsDeep(gg.Wrap(errs, `wrap`, gg.ErrAttrOf(`wrap`, 40))),
+		append(gg.ErrAttrs{{`wrap`, 40}}, exp...),
+	)
+
+	gtest.Equal(exp.Map(), map[string]any{`key`: 20, `other`: 30})
+}
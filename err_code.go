@@ -0,0 +1,163 @@
+package gg
+
+/*
+Error code: machine-readable kind of an error, suitable for mapping errors to
+HTTP statuses, retry policies, and so on. Similar to `ErrStr`: implements
+`error` and can be defined as a constant. Codes can be used as errors
+directly, or attached to an `Err` via `Err.Coded` or by passing the code to
+`Errv`, `Errf`, `Wrap`, `Wrapf`, `Detail`, `Detailf`. Because such arguments
+are excluded from the message, a code meant to be printed in the message must
+be converted to `string` first. See `ErrCodeOf` for finding the nearest code
+of an arbitrary error.
+
+Codes may be classified via `ErrCodeReg`. Usage:
+
+	const ErrCodeNotFound gg.ErrCode = `not_found`
+	const ErrCodeUnavailable gg.ErrCode = `unavailable`
+
+	func init() {
+		gg.ErrCodeReg(ErrCodeNotFound, gg.ErrClassPermanent)
+		gg.ErrCodeReg(ErrCodeUnavailable, gg.ErrClassRetryable)
+	}
+
+	func findUser(id string) User {
+		if !found {
+			panic(gg.Errf(`user %q not found`, id, ErrCodeNotFound))
+		}
+		...
+	}
+
+	func handle(err error) {
+		if errors.Is(err, ErrCodeNotFound) {
+			// Respond with 404.
+		}
+		if gg.IsErrRetryable(err) {
+			// Schedule a retry.
+		}
+	}
+*/
+type ErrCode string
+
+// Implement `error`.
+func (self ErrCode) Error() string { return string(self) }
+
+// Implement `fmt.Stringer`.
+func (self ErrCode) String() string { return string(self) }
+
+// Implement a hidden interface for compatibility with `"errors".Is`.
+func (self ErrCode) Is(err error) bool {
+	tar, ok := err.(ErrCode)
+	return ok && self == tar
+}
+
+// Implement `ErrCoder` by returning self.
+func (self ErrCode) OwnCode() ErrCode { return self }
+
+/*
+True if the given error itself, without unwrapping, has this code. Suitable
+as a predicate for `ErrFind`, `ErrSome`, `Errs.Find`, `Errs.Some`:
+
+	gg.ErrSome(err, ErrCodeNotFound.Match)
+*/
+func (self ErrCode) Match(err error) bool {
+	return self != `` && errOwnCode(err) == self
+}
+
+/*
+Returns the classification of this code, previously registered via
+`ErrCodeReg`. For unregistered codes, returns `ErrClassUnknown`.
+*/
+func (self ErrCode) Class() ErrClass {
+	out, _ := errCodes.Load(self)
+	return out
+}
+
+/*
+Registers the classification of the given error code, returning the code.
+Intended for static declarations, usually in `init`. Panics on an attempt to
+register an empty code or to change the class of an already-registered code.
+Safe for concurrent use.
+*/
+func ErrCodeReg(code ErrCode, class ErrClass) ErrCode {
+	if code == `` {
+		panic(Errv(`unable to register empty error code`))
+	}
+
+	prev, ok := errCodes.LoadOrStore(code, class)
+	if ok && prev != class {
+		panic(Errf(
+			`unable to register error code %q as %v: already registered as %v`,
+			string(code), class, prev,
+		))
+	}
+	return code
+}
+
+// Classification of error codes. See `ErrCodeReg`.
+type ErrClass uint8
+
+const (
+	ErrClassUnknown ErrClass = iota
+	ErrClassPermanent
+	ErrClassRetryable
+)
+
+// Implement `fmt.Stringer`.
+func (self ErrClass) String() string {
+	switch self {
+	case ErrClassUnknown:
+		return `unknown`
+	case ErrClassPermanent:
+		return `permanent`
+	case ErrClassRetryable:
+		return `retryable`
+	default:
+		return `ErrClass(` + String(uint8(self)) + `)`
+	}
+}
+
+// Returns a modified version with the given `.Code`.
+func (self Err) Coded(val ErrCode) Err {
+	self.Code = val
+	return self
+}
+
+// Implement `ErrCoder` by returning `.Code`.
+func (self Err) OwnCode() ErrCode { return self.Code }
+
+/*
+Returns the nearest non-empty error code in the given error's chain, using
+`ErrFind` to unwrap, which also descends into `Errs`. If no code is found,
+returns an empty code.
+*/
+func ErrCodeOf(err error) ErrCode {
+	return errOwnCode(ErrFind(err, hasErrOwnCode))
+}
+
+/*
+Returns the classification of the nearest error code in the given error's
+chain. Shortcut for `ErrCodeOf(err).Class()`.
+*/
+func ErrClassOf(err error) ErrClass { return ErrCodeOf(err).Class() }
+
+// True if the nearest error code is classified as `ErrClassRetryable`.
+func IsErrRetryable(err error) bool {
+	return ErrClassOf(err) == ErrClassRetryable
+}
+
+// True if the nearest error code is classified as `ErrClassPermanent`.
+func IsErrPermanent(err error) bool {
+	return ErrClassOf(err) == ErrClassPermanent
+}
+
+var errCodes SyncMap[ErrCode, ErrClass]
+
+func errOwnCode(err error) (_ ErrCode) {
+	impl, _ := err.(ErrCoder)
+	if impl != nil {
+		return impl.OwnCode()
+	}
+	return
+}
+
+func hasErrOwnCode(err error) bool { return errOwnCode(err) != `` }
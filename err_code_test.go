@@ -0,0 +1,135 @@
+package gg_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+const (
+	testErrCodePerm  gg.ErrCode = `test_perm`
+	testErrCodeRetry gg.ErrCode = `test_retry`
+	testErrCodeUnreg gg.ErrCode = `test_unreg`
+)
+
+func init() {
+	gg.ErrCodeReg(testErrCodePerm, gg.ErrClassPermanent)
+	gg.ErrCodeReg(testErrCodeRetry, gg.ErrClassRetryable)
+}
+
+func TestErrCodeReg(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Eq(testErrCodePerm.Class(), gg.ErrClassPermanent)
+	gtest.Eq(testErrCodeRetry.Class(), gg.ErrClassRetryable)
+	gtest.Eq(testErrCodeUnreg.Class(), gg.ErrClassUnknown)
+
+	// Idempotent for the same class.
+	gtest.Eq(gg.ErrCodeReg(testErrCodePerm, gg.ErrClassPermanent), testErrCodePerm)
+
+	gtest.PanicStr(`unable to register empty error code`, func() {
+		gg.ErrCodeReg(``, gg.ErrClassPermanent)
+	})
+
+	gtest.PanicStr(`already registered as permanent`, func() {
+		gg.ErrCodeReg(testErrCodePerm, gg.ErrClassRetryable)
+	})
+}
+
+func TestErrClass_String(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Eq(gg.ErrClassUnknown.String(), `unknown`)
+	gtest.Eq(gg.ErrClassPermanent.String(), `permanent`)
+	gtest.Eq(gg.ErrClassRetryable.String(), `retryable`)
+	gtest.Eq(gg.ErrClass(10).String(), `ErrClass(10)`)
+}
+
+func TestErr_Coded(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Eq(gg.Err{}.Coded(testErrCodePerm).Code, testErrCodePerm)
+	gtest.Eq(gg.Errf(`one %v`, 10, testErrCodePerm).Code, testErrCodePerm)
+	gtest.Eq(gg.Errf(`one %v`, 10, testErrCodePerm).Msg, `one 10`)
+	gtest.Eq(gg.Errv(`one `, 10, testErrCodePerm).Msg, `one 10`)
+
+	err := gg.Wrap(io.EOF, `unable to read`, testErrCodeRetry).(gg.Err)
+	gtest.Eq(err.Code, testErrCodeRetry)
+	gtest.Eq(err.Error(), `unable to read: EOF`)
+
+	err = gg.Catch(func() {
+		defer gg.Detailf(`unable to %v`, `read`, testErrCodePerm)
+		panic(io.EOF)
+	}).(gg.Err)
+	gtest.Eq(err.Code, testErrCodePerm)
+	gtest.Eq(err.Error(), `unable to read: EOF`)
+}
+
+func TestErrCodeOf(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Zero(gg.ErrCodeOf(nil))
+	gtest.Zero(gg.ErrCodeOf(io.EOF))
+	gtest.Zero(gg.ErrCodeOf(gg.Errf(`one`)))
+
+	gtest.Eq(gg.ErrCodeOf(testErrCodePerm), testErrCodePerm)
+	gtest.Eq(gg.ErrCodeOf(gg.Wrap(testErrCodePerm, `wrap`)), testErrCodePerm)
+
+	inner := gg.Errf(`inner`, testErrCodePerm)
+	outer := gg.Wrap(inner, `outer`, testErrCodeRetry)
+
+	gtest.Eq(gg.ErrCodeOf(inner), testErrCodePerm)
+	gtest.Eq(gg.ErrCodeOf(outer), testErrCodeRetry)
+	gtest.Eq(gg.ErrCodeOf(gg.Wrap(inner, `outer`)), testErrCodePerm)
+	gtest.Eq(gg.ErrCodeOf(fmt.Errorf(`foreign: %w`, inner)), testErrCodePerm)
+
+	gtest.Eq(
+		gg.ErrCodeOf(gg.Errs{nil, io.EOF, gg.Wrap(inner, `wrap`), outer}),
+		testErrCodePerm,
+	)
+
+	gtest.Eq(gg.ErrClassOf(inner), gg.ErrClassPermanent)
+	gtest.Eq(gg.ErrClassOf(outer), gg.ErrClassRetryable)
+	gtest.Eq(gg.ErrClassOf(gg.Errf(`one`, testErrCodeUnreg)), gg.ErrClassUnknown)
+
+	gtest.True(gg.IsErrPermanent(inner))
+	gtest.False(gg.IsErrRetryable(inner))
+	gtest.True(gg.IsErrRetryable(outer))
+	gtest.False(gg.IsErrPermanent(outer))
+	gtest.False(gg.IsErrPermanent(io.EOF))
+	gtest.False(gg.IsErrRetryable(io.EOF))
+}
+
+func TestErrCode_interop(t *testing.T) {
+	defer gtest.Catch(t)
+
+	inner := gg.Errf(`inner`, testErrCodePerm)
+	outer := fmt.Errorf(`foreign: %w`, gg.Wrap(inner, `outer`, testErrCodeRetry))
+
+	gtest.True(errors.Is(inner, testErrCodePerm))
+	gtest.False(errors.Is(inner, testErrCodeRetry))
+	gtest.True(errors.Is(outer, testErrCodePerm))
+	gtest.True(errors.Is(outer, testErrCodeRetry))
+	gtest.False(errors.Is(outer, testErrCodeUnreg))
+	gtest.True(errors.Is(gg.Errs{io.EOF, inner}, testErrCodePerm))
+	gtest.False(errors.Is(gg.Errf(`uncoded`), gg.ErrCode(``)))
+	gtest.False(errors.Is(outer, gg.ErrCode(``)))
+
+	gtest.True(gg.ErrSome(outer, testErrCodePerm.Match))
+	gtest.True(gg.ErrSome(outer, testErrCodeRetry.Match))
+	gtest.False(gg.ErrSome(outer, testErrCodeUnreg.Match))
+	gtest.False(gg.ErrSome(outer, gg.ErrCode(``).Match))
+	gtest.Equal(gg.ErrFind(outer, testErrCodePerm.Match), error(inner))
+	gtest.True(gg.Errs{io.EOF, outer}.Some(testErrCodePerm.Match))
+
+	gtest.Eq(gg.ErrAs[gg.ErrCode](nil), ``)
+	gtest.Eq(gg.ErrAs[gg.ErrCode](io.EOF), ``)
+	gtest.Eq(gg.ErrAs[gg.ErrCode](gg.Errf(`uncoded`)), ``)
+	gtest.Eq(gg.ErrAs[gg.ErrCode](inner), testErrCodePerm)
+	gtest.Eq(gg.ErrAs[gg.ErrCode](outer), testErrCodeRetry)
+	gtest.Eq(gg.ErrAs[gg.ErrCode](gg.Wrap(testErrCodePerm, `wrap`)), testErrCodePerm)
+}
@@ -0,0 +1,132 @@
+package gg
+
+/*
+Group of errors with identical representations, including stack traces.
+Returned by `Errs.Group`.
+*/
+type ErrGroup struct {
+	Err   error
+	Count int
+}
+
+/*
+Groups non-nil errors whose representations with stack traces, as printed by
+`Buf.AppendErrorStack`, are identical. The groups are ordered by first
+occurrence. Used by `Errs.AppendStackTo` to print each group once, which is
+useful for errors collected by `ConcCatch` and similar functions, where many
+goroutines often fail in the same way.
+*/
+func (self Errs) Group() []ErrGroup {
+	var out []ErrGroup
+	var index map[string]int
+
+	for _, val := range self {
+		if val == nil {
+			continue
+		}
+
+		var buf Buf
+		buf.AppendErrorStack(val)
+		key := buf.String()
+
+		ind, ok := index[key]
+		if ok {
+			out[ind].Count++
+			continue
+		}
+
+		MapInit(&index)[key] = len(out)
+		out = append(out, ErrGroup{val, 1})
+	}
+	return out
+}
+
+func errsAppendStackGrouped(inout []byte, src Errs) []byte {
+	groups := src.Group()
+	shared := errGroupsTrimTrace(groups)
+
+	buf := Buf(inout)
+	buf.AppendString(`multiple errors:`)
+
+	for _, val := range groups {
+		buf.AppendNewlines(2)
+		if val.Count > 1 {
+			buf.AppendInt(val.Count)
+			buf.AppendString(` identical errors:`)
+			buf.AppendNewline()
+		}
+
+		if isErrTree(val.Err) {
+			buf = errTreeAppend(buf, val.Err, 0, true)
+		} else {
+			buf.AppendErrorStack(val.Err)
+		}
+	}
+
+	if len(shared) > 0 {
+		buf.AppendNewlines(2)
+		buf.AppendString(`shared trace:`)
+		buf.AppendBytes(shared)
+	}
+	return buf
+}
+
+/*
+If there are multiple groups and each is an `Err` with its own trace, and the
+traces have a common suffix, removes the suffix from the traces and returns its
+representation. Mutates the slice but not the errors.
+*/
+func errGroupsTrimTrace(src []ErrGroup) []byte {
+	if len(src) < 2 {
+		return nil
+	}
+
+	var shared Trace
+	for ind, val := range src {
+		err, ok := val.Err.(Err)
+		if !ok || err.OwnTrace().IsEmpty() {
+			return nil
+		}
+
+		if ind == 0 {
+			shared = err.OwnTrace()
+		} else {
+			shared = traceCommonSuffix(shared, err.OwnTrace())
+		}
+	}
+
+	out := shared.AppendIndentTo(nil, 1)
+	if len(out) <= 0 {
+		return nil
+	}
+
+	for ind := range src {
+		err := src[ind].Err.(Err)
+		trace := err.OwnTrace()
+		trace = trace[:len(trace)-len(shared)]
+
+		if trace.IsEmpty() {
+			err.Trace = &errTraceShared
+		} else {
+			err.Trace = &trace
+		}
+		src[ind].Err = err
+	}
+	return out
+}
+
+/*
+Placeholder for a trace which was entirely moved to the shared trace by
+`errGroupsTrimTrace`. Compared by pointer, see `Err.isTraceShared`, which
+distinguishes it from empty traces obtained in other ways.
+*/
+var errTraceShared Trace
+
+func traceCommonSuffix(one, two Trace) Trace {
+	var size int
+	for size < len(one) && size < len(two) &&
+		one[len(one)-size-1] == two[len(two)-size-1] {
+		size++
+	}
+	return one[len(one)-size:]
+}
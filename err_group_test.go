@@ -0,0 +1,108 @@
+package gg_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+func TestErrs_Group(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Zero(gg.Errs(nil).Group())
+	gtest.Zero(gg.Errs{nil, nil}.Group())
+
+	gtest.Equal(
+		gg.Errs{nil, io.EOF, testErrUntracedA, io.EOF, nil, io.EOF}.Group(),
+		[]gg.ErrGroup{{io.EOF, 3}, {testErrUntracedA, 1}},
+	)
+
+	// Same message, different traces.
+	other := gg.Errf(testErrTraced0.Error())
+	gtest.Equal(
+		gg.Errs{testErrTraced0, other}.Group(),
+		[]gg.ErrGroup{{testErrTraced0, 1}, {other, 1}},
+	)
+}
+
+func TestErrs_AppendStackTo_grouped(t *testing.T) {
+	defer gtest.Catch(t)
+
+	t.Run(`untraced`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		gtest.Eq(
+			fmt.Sprintf(`%+v`, gg.Errs{io.EOF, nil, io.EOF, testErrUntracedA}),
+			"multiple errors:\n\n2 identical errors:\nEOF\n\n"+testErrUntracedA.Error(),
+		)
+	})
+
+	t.Run(`identical`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		errs := gg.Errs(gg.ConcEachCatch([]int{0, 1, 2}, func(int) {
+			panic(gg.Errf(`fail`))
+		}))
+		gtest.Len(errs.Group(), 1)
+
+		out := fmt.Sprintf(`%+v`, errs)
+		gtest.TextHas(out, "multiple errors:\n\n3 identical errors:\ntrace:\n")
+		gtest.Eq(strings.Count(out, `fail`), 1)
+		gtest.Eq(strings.Count(out, `TestErrs_AppendStackTo_grouped.func2.1`), 1)
+		gtest.NotTextHas(out, `shared trace:`)
+	})
+
+	t.Run(`shared_suffix`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		errs := gg.Errs(gg.ConcEachCatch([]int{0, 1, 2, 3}, func(val int) {
+			if val%2 == 0 {
+				panic(gg.Errf(`even`))
+			}
+			panic(gg.Errf(`odd`))
+		}))
+		gtest.Len(errs.Group(), 2)
+
+		out := fmt.Sprintf(`%+v`, errs)
+		gtest.TextHas(out, "multiple errors:\n\n2 identical errors:\neven\ntrace:\n")
+		gtest.TextHas(out, "\n\n2 identical errors:\nodd\ntrace:\n")
+		gtest.TextHas(out, "\n\nshared trace:\n    gg.ConcEachCatch")
+		gtest.Eq(strings.Count(out, `gg.ConcEachCatch`), 1)
+		gtest.Eq(strings.Count(out, `gg_test.TestErrs_AppendStackTo_grouped.func3 `), 1)
+
+		// The original errors are unchanged.
+		gtest.Eq(
+			fmt.Sprintf(`%+v`, errs[0]),
+			fmt.Sprintf(`%+v`, errs[2]),
+		)
+		gtest.TextHas(fmt.Sprintf(`%+v`, errs[0]), `gg.ConcEachCatch`)
+	})
+
+	t.Run(`no_shared_suffix_for_foreign`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		out := fmt.Sprintf(`%+v`, gg.Errs{testErrTraced0, io.EOF})
+		gtest.NotTextHas(out, `shared trace:`)
+		gtest.TextHas(out, gg.ErrStack(testErrTraced0))
+	})
+
+	t.Run(`entire_trace_shared`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		trace := gg.Ptr(gg.CaptureTrace(0))
+		errs := gg.Errs{
+			gg.Err{Msg: `one`, Cause: testErrTraced0, Trace: trace},
+			gg.Err{Msg: `two`, Cause: testErrTraced1, Trace: trace},
+		}
+
+		// The trace of each cause must not be printed as the outer trace.
+		out := fmt.Sprintf(`%+v`, errs)
+		gtest.TextHas(out, "multiple errors:\n\none\ncause: "+gg.ErrStack(testErrTraced0))
+		gtest.TextHas(out, "\n\ntwo\ncause: "+gg.ErrStack(testErrTraced1))
+		gtest.TextHas(out, "\n\nshared trace:\n")
+	})
+}
@@ -0,0 +1,161 @@
+package gg
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+/*
+Implement `json.Marshaler`. Encodes the message, code, attributes, stack trace,
+and cause, suitable for sending errors across process boundaries. The trace
+is encoded via `Trace.MarshalJSON`. A cause of type `Err` or `Errs` is encoded
+recursively. Causes of other types are flattened into an `Err` whose message
+is the cause's `.Error`, keeping its trace, code, and attributes, if any.
+
+Attribute values are encoded via `json.Marshal`, and after decoding, have the
+types produced by `json.Unmarshal` for `any`, such as `float64` for numbers.
+*/
+func (self Err) MarshalJSON() ([]byte, error) {
+	cause, err := errJsonEncode(self.Cause)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(errJson{
+		Msg:   self.Msg,
+		Code:  self.Code,
+		Attrs: self.OwnAttrs(),
+		Trace: self.OwnTrace(),
+		Cause: cause,
+	})
+}
+
+/*
+Implement `json.Unmarshaler`. Decodes the representation created by
+`Err.MarshalJSON`. The decoded error prints via `%+v` the same way as the
+original, see `Trace.UnmarshalJSON` for how the trace is preserved.
+*/
+func (self *Err) UnmarshalJSON(src []byte) error {
+	if IsJsonEmpty(src) {
+		*self = Err{}
+		return nil
+	}
+
+	var tar errJson
+	err := json.Unmarshal(src, &tar)
+	if err != nil {
+		return err
+	}
+
+	cause, err := errJsonDecode(tar.Cause)
+	if err != nil {
+		return err
+	}
+
+	out := Err{Msg: tar.Msg, Cause: cause, Code: tar.Code}
+	if tar.Trace != nil {
+		out.Trace = &tar.Trace
+	}
+	if tar.Attrs != nil {
+		out.Attrs = &tar.Attrs
+	}
+	*self = out
+	return nil
+}
+
+/*
+Implement `json.Marshaler`. Encodes the errors as an array, where each element
+is encoded like in `Err.MarshalJSON`. Nil errors are encoded as nulls,
+preserving the positions of other errors.
+*/
+func (self Errs) MarshalJSON() ([]byte, error) {
+	if self == nil {
+		return ToBytes(`null`), nil
+	}
+
+	out := make([]json.RawMessage, len(self))
+	for ind, val := range self {
+		var err error
+		out[ind], err = errJsonEncode(val)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(out)
+}
+
+/*
+Implement `json.Unmarshaler`. Decodes the representation created by
+`Errs.MarshalJSON`, where each non-null element is decoded as either `Errs`
+or `Err`.
+*/
+func (self *Errs) UnmarshalJSON(src []byte) error {
+	if IsJsonEmpty(src) {
+		*self = nil
+		return nil
+	}
+
+	var tar []json.RawMessage
+	err := json.Unmarshal(src, &tar)
+	if err != nil {
+		return err
+	}
+
+	out := make(Errs, len(tar))
+	for ind, val := range tar {
+		out[ind], err = errJsonDecode(val)
+		if err != nil {
+			return err
+		}
+	}
+	*self = out
+	return nil
+}
+
+type errJson struct {
+	Msg   string          `json:"message,omitempty"`
+	Code  ErrCode         `json:"code,omitempty"`
+	Attrs ErrAttrs        `json:"attrs,omitempty"`
+	Trace Trace           `json:"trace,omitempty"`
+	Cause json.RawMessage `json:"cause,omitempty"`
+}
+
+func errJsonEncode(src error) (json.RawMessage, error) {
+	switch src := src.(type) {
+	case nil:
+		return nil, nil
+	case Err:
+		return src.MarshalJSON()
+	case Errs:
+		return src.MarshalJSON()
+	default:
+		out := Err{Msg: src.Error(), Code: ErrCodeOf(src)}
+
+		trace := ErrTrace(src)
+		if trace.IsNotEmpty() {
+			out.Trace = &trace
+		}
+
+		attrs := ErrAttrsDeep(src)
+		if len(attrs) > 0 {
+			out.Attrs = &attrs
+		}
+		return out.MarshalJSON()
+	}
+}
+
+func errJsonDecode(src json.RawMessage) (error, error) {
+	if IsJsonEmpty(src) {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(ToString(src)), `[`) {
+		var out Errs
+		err := out.UnmarshalJSON(src)
+		return out, err
+	}
+
+	var out Err
+	err := out.UnmarshalJSON(src)
+	return out, err
+}
@@ -0,0 +1,157 @@
+package gg_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+func TestTrace_MarshalJSON(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Eq(gg.JsonString(gg.Trace(nil)), `null`)
+	gtest.Eq(gg.JsonString(gg.Trace{}), `[]`)
+	gtest.Eq(gg.JsonString(gg.Trace{0}), `[]`)
+
+	src := trace0()
+	frames := src.Frames()
+	enc := gg.JsonString(src)
+
+	var dec []struct {
+		Func string `json:"func"`
+		File string `json:"file"`
+		Line int    `json:"line"`
+	}
+	gg.JsonDecode(enc, &dec)
+
+	gtest.Eq(len(dec), len(frames))
+	gtest.Eq(dec[0].Func, `github.com/mitranim/gg_test.trace9`)
+	gtest.Eq(dec[0].File, frames[0].File)
+	gtest.Eq(dec[0].Line, frames[0].Line)
+}
+
+func TestTrace_UnmarshalJSON(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Zero(gg.JsonDecodeTo[gg.Trace](`null`))
+
+	src := trace0()
+	out := gg.JsonDecodeTo[gg.Trace](gg.JsonString(src))
+
+	gtest.Eq(len(out), len(src))
+	gtest.True(out.IsNotEmpty())
+	gtest.True(gg.Every(out, isCallerForeign))
+	gtest.False(gg.Some(src, isCallerForeign))
+	gtest.Eq(out.String(), src.String())
+	gtest.Eq(out.Table(), src.Table())
+
+	// Same frames are assigned the same synthetic callers.
+	gtest.Equal(gg.JsonDecodeTo[gg.Trace](gg.JsonString(src)), out)
+
+	// Re-encoding preserves the frames.
+	gtest.Eq(gg.JsonString(out), gg.JsonString(src))
+
+	frame := gg.Caller(out[0]).Frame()
+	gtest.True(frame.IsValid())
+	gtest.Zero(frame.Func)
+	gtest.Eq(frame.Name, `gg_test.trace9`)
+	gtest.Eq(frame.FullName, `github.com/mitranim/gg_test.trace9`)
+}
+
+func isCallerForeign(val uintptr) bool { return gg.Caller(val).IsForeign() }
+
+func TestErr_MarshalJSON(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Eq(gg.JsonString(gg.Err{}), `{}`)
+	gtest.Eq(gg.JsonString(gg.Err{Msg: `one`}), `{"message":"one"}`)
+
+	gtest.Eq(
+		gg.JsonString(gg.Err{Msg: `one`, Code: `two`, Cause: io.EOF}.Attrd(gg.ErrAttrOf(`key`, 10))),
+		`{"message":"one","code":"two","attrs":[{"key":"key","value":10}],"cause":{"message":"EOF"}}`,
+	)
+
+	gtest.Eq(
+		gg.JsonString(gg.Err{Msg: `one`, Cause: gg.Errs{nil, io.EOF}}),
+		`{"message":"one","cause":[null,{"message":"EOF"}]}`,
+	)
+}
+
+func TestErr_UnmarshalJSON(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Zero(gg.JsonDecodeTo[gg.Err](`null`))
+	gtest.Zero(gg.JsonDecodeTo[gg.Err](`{}`))
+
+	t.Run(`untraced`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		src := gg.Err{Msg: `one`, Code: testErrCodePerm, Cause: io.EOF}
+		out := gg.JsonDecodeTo[gg.Err](gg.JsonString(src))
+
+		gtest.Eq(out.Error(), src.Error())
+		gtest.Eq(out.Code, testErrCodePerm)
+		gtest.Equal(out.Cause, error(gg.Err{Msg: `EOF`}))
+		gtest.True(errors.Is(out, testErrCodePerm))
+		gtest.Zero(out.Trace)
+		gtest.Zero(out.Attrs)
+	})
+
+	t.Run(`traced`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		inner := gg.Errf(`inner`, gg.ErrAttrOf(`key`, `val`))
+		outer := gg.Wrap(gg.Errs{nil, inner, gg.Errf(`other`)}, `outer`).(gg.Err).TracedAt(0)
+		out := gg.JsonDecodeTo[gg.Err](gg.JsonString(outer))
+
+		gtest.Eq(out.Error(), outer.Error())
+		gtest.Eq(fmt.Sprintf(`%+v`, out), fmt.Sprintf(`%+v`, outer))
+		gtest.Eq(out.Stack(), outer.Stack())
+		gtest.True(out.IsTraced())
+		gtest.Eq(gg.ErrAttrGet[string](out, `key`), `val`)
+		gtest.Len(out.Cause.(gg.Errs), 3)
+		gtest.Zero(out.Cause.(gg.Errs)[0])
+	})
+
+	t.Run(`foreign_wrapper`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		src := fmt.Errorf(`foreign: %w`, gg.Errf(`inner`, testErrCodeRetry))
+		out := gg.JsonDecodeTo[gg.Err](gg.JsonString(gg.Err{Msg: `outer`, Cause: src}))
+
+		gtest.Eq(out.Error(), `outer: foreign: inner`)
+		gtest.True(gg.IsErrRetryable(out))
+		gtest.Eq(gg.ErrTrace(out).Table(), gg.ErrTrace(src).Table())
+	})
+
+	t.Run(`invalid`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var tar gg.Err
+		gtest.NotZero(json.Unmarshal([]byte(`"str"`), &tar))
+		gtest.NotZero(json.Unmarshal([]byte(`{"cause":"str"}`), &tar))
+	})
+}
+
+func TestErrs_JSON(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Eq(gg.JsonString(gg.Errs(nil)), `null`)
+	gtest.Eq(gg.JsonString(gg.Errs{}), `[]`)
+	gtest.Eq(gg.JsonString(gg.Errs{nil}), `[null]`)
+	gtest.Zero(gg.JsonDecodeTo[gg.Errs](`null`))
+	gtest.Equal(gg.JsonDecodeTo[gg.Errs](`[]`), gg.Errs{})
+
+	src := gg.Errs{nil, testErrTraced0, gg.Errs{testErrUntracedA, testErrTraced1}}
+	out := gg.JsonDecodeTo[gg.Errs](gg.JsonString(src))
+
+	gtest.Len(out, 3)
+	gtest.Zero(out[0])
+	gtest.Eq(out.Error(), src.Error())
+	gtest.Eq(fmt.Sprintf(`%+v`, out), fmt.Sprintf(`%+v`, src))
+}
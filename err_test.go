@@ -112,6 +112,22 @@ trace:
     gg_test.TestErrStack.func6 err_test.go:103
 `))
 	})
+
+	t.Run(`Err_inner_traced_outer_empty_trace`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		inner := gg.Err{Msg: `inner`}.TracedAt(0)
+
+		gtest.Eq(
+			gg.ErrStack(gg.Err{Cause: inner, Trace: new(gg.Trace)}),
+			inner.Stack(),
+		)
+
+		gtest.Eq(
+			gg.ErrStack(gg.Err{Msg: `outer`, Cause: inner, Trace: new(gg.Trace)}),
+			`outer: `+inner.Stack(),
+		)
+	})
 }
 
 func TestErrTrace(t *testing.T) {
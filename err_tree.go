@@ -0,0 +1,178 @@
+package gg
+
+/*
+Returns a tree-shaped representation of the error messages, without stack
+traces. Same as `ErrTree(self)`.
+*/
+func (self Errs) Tree() string { return ToString(self.AppendTreeTo(nil)) }
+
+/*
+Appends a tree-shaped representation of the error messages, without stack
+traces. The representation is the same as in `.Tree`.
+*/
+func (self Errs) AppendTreeTo(buf []byte) []byte { return errTreeAppend(buf, self, 0, false) }
+
+/*
+Returns a tree-shaped representation of the error messages with stack traces.
+Same as `ErrTreeStack(self)`.
+*/
+func (self Errs) TreeStack() string { return ToString(self.AppendTreeStackTo(nil)) }
+
+/*
+Appends a tree-shaped representation of the error messages with stack traces.
+The representation is the same as in `.TreeStack`.
+*/
+func (self Errs) AppendTreeStackTo(buf []byte) []byte {
+	return errTreeAppend(buf, self, 0, true)
+}
+
+/*
+Returns a tree-shaped representation of the given error, without stack traces.
+Unlike `.Error`, which flattens nested errors into one line, this prints each
+multi-error as a header line followed by its children, indented one level
+deeper than the header. Supports `Errs`, including `Errs` in the `.Cause` of
+`Err`, and foreign multi-errors which implement `Unwrap() []error`, such as
+those returned by `errors.Join`. Other errors are printed as single nodes. If
+the error is nil, the output is empty. Also see `ErrTreeStack`. Example output:
+
+	request failed:
+	    connection refused
+	    multiple errors:
+	        unexpected EOF
+	        timeout
+*/
+func ErrTree(err error) string { return ToString(errTreeAppend(nil, err, 0, false)) }
+
+/*
+Similar to `ErrTree`, but each node is followed by its stack trace, if any,
+indented like in `ErrStack`. For `Err`, the node's trace is the outermost trace
+in its chain of causes.
+*/
+func ErrTreeStack(err error) string { return ToString(errTreeAppend(nil, err, 0, true)) }
+
+/*
+If the given error is a multi-error with at least two non-nil children, returns
+its children, unwrapping multi-errors with only one non-nil child. Also returns the
+messages and the outermost trace of any `Err` wrapping the multi-error. For
+other errors, the children are nil.
+*/
+func errTreeSplit(err error) (msgs []string, trace Trace, children []error) {
+	for err != nil {
+		switch val := err.(type) {
+		case Err:
+			if val.Msg != `` {
+				msgs = append(msgs, val.Msg)
+			}
+			if trace.IsEmpty() {
+				trace = val.OwnTrace()
+			}
+			err = val.Cause
+
+		case Errs:
+			one, count := val.find()
+			if count <= 1 {
+				err = one
+				continue
+			}
+			return msgs, trace, val
+
+		case interface{ Unwrap() []error }:
+			children := val.Unwrap()
+			one, count := Errs(children).find()
+			if count <= 1 {
+				err = one
+				continue
+			}
+			return msgs, trace, children
+
+		default:
+			return msgs, trace, nil
+		}
+	}
+	return msgs, trace, nil
+}
+
+func errTreeAppend(inout []byte, err error, lvl int, stack bool) []byte {
+	buf := Buf(inout)
+	if err == nil {
+		return buf
+	}
+
+	msgs, trace, children := errTreeSplit(err)
+
+	if children == nil {
+		errTreeAppendMsg(&buf, err.Error(), lvl)
+		if stack {
+			if trace.IsEmpty() {
+				trace = ErrTrace(err)
+			}
+			buf = errTreeAppendTrace(buf, trace, lvl)
+		}
+		return buf
+	}
+
+	if len(msgs) > 0 {
+		for ind, val := range msgs {
+			if ind > 0 {
+				buf.AppendString(`: `)
+			}
+			errTreeAppendMsg(&buf, val, lvl)
+		}
+		buf.AppendString(`:`)
+	} else {
+		buf.AppendString(`multiple errors:`)
+	}
+
+	if stack {
+		buf = errTreeAppendTrace(buf, trace, lvl)
+	}
+
+	for _, val := range children {
+		if val == nil {
+			continue
+		}
+		buf.AppendNewline()
+		buf.AppendIndents(lvl + 1)
+		buf = errTreeAppend(buf, val, lvl+1, stack)
+	}
+	return buf
+}
+
+// Indents continuation lines of multi-line messages, such as from `errors.Join`.
+func errTreeAppendMsg(buf *Buf, msg string, lvl int) {
+	for ind, val := range SplitLines(msg) {
+		if ind > 0 {
+			buf.AppendNewline()
+			buf.AppendIndents(lvl)
+		}
+		buf.AppendString(val)
+	}
+}
+
+func errTreeAppendTrace(buf Buf, trace Trace, lvl int) Buf {
+	if trace.IsEmpty() {
+		return buf
+	}
+
+	size := len(buf)
+	buf.AppendNewline()
+	buf.AppendIndents(lvl)
+	buf.AppendString(`trace:`)
+	prev := len(buf)
+	buf = trace.AppendIndentTo(buf, lvl+1)
+
+	// All frames may be hidden by `TraceSkipLang` or `TraceFilter`.
+	if len(buf) == prev {
+		return buf[:size]
+	}
+	return buf
+}
+
+/*
+True if the error is a multi-error with at least two non-nil children, possibly
+wrapped in `Err`. Such errors are printed as trees by `Errs.AppendStackTo`.
+*/
+func isErrTree(err error) bool {
+	_, _, children := errTreeSplit(err)
+	return children != nil
+}
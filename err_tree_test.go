@@ -0,0 +1,129 @@
+package gg_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+func testErrsNested() gg.Errs {
+	return gg.Errs{
+		io.EOF,
+		nil,
+		gg.Err{Msg: `outer`, Cause: gg.Errs{io.ErrUnexpectedEOF, gg.ErrStr(`inner`)}},
+		errors.Join(io.ErrClosedPipe, gg.Errs{nil, io.ErrNoProgress}),
+	}
+}
+
+func TestErrTree(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Zero(gg.ErrTree(nil))
+	gtest.Eq(gg.ErrTree(io.EOF), `EOF`)
+	gtest.Eq(gg.ErrTree(gg.Errs{nil, io.EOF}), `EOF`)
+	gtest.Eq(gg.ErrTree(errors.Join(io.EOF)), `EOF`)
+	gtest.Eq(gg.ErrTree(errors.Join(nil, io.EOF, nil)), `EOF`)
+	gtest.Eq(gg.ErrTree(gg.Wrap(errors.Join(io.EOF), `outer`)), `outer: EOF`)
+	gtest.Eq(gg.ErrTree(gg.Wrap(io.EOF, `outer`)), `outer: EOF`)
+
+	gtest.Eq(
+		testErrsNested().Tree(),
+		`multiple errors:
+    EOF
+    outer:
+        unexpected EOF
+        inner
+    multiple errors:
+        io: read/write on closed pipe
+        multiple Read calls return no data or error`,
+	)
+
+	gtest.Eq(
+		gg.ErrTree(gg.Err{Msg: `one`, Cause: gg.Err{Msg: `two`, Cause: gg.Errs{io.EOF, io.EOF}}}),
+		`one: two:
+    EOF
+    EOF`,
+	)
+
+	gtest.Eq(
+		gg.ErrTree(gg.Errs{gg.ErrStr("one\ntwo"), gg.Errs{io.EOF, gg.ErrStr("three\nfour")}}),
+		`multiple errors:
+    one
+    two
+    multiple errors:
+        EOF
+        three
+        four`,
+	)
+}
+
+func TestErrTreeStack(t *testing.T) {
+	defer gtest.Catch(t)
+	defer gg.SnapSwap(&gg.TraceTable, false).Done()
+
+	err := gg.Errs{testErrTraced0, io.EOF}
+	out := gg.ErrTreeStack(gg.Errs{gg.Err{Msg: `outer`, Cause: err}.TracedAt(0), io.EOF})
+
+	gtest.TextHas(out, `multiple errors:
+    outer:
+    trace:
+        `)
+	gtest.TextHas(out, `
+        test err traced 0
+        trace:
+            `)
+	gtest.True(gg.Has(gg.SplitLines(out), `        EOF`))
+	gtest.True(gg.Has(gg.SplitLines(out), `    EOF`))
+
+	gtest.Eq(gg.ErrTreeStack(io.EOF), `EOF`)
+	gtest.Eq(gg.ErrTreeStack(testErrTraced0), gg.ErrStack(testErrTraced0))
+}
+
+func TestErrs_AppendStackTo_nested(t *testing.T) {
+	defer gtest.Catch(t)
+
+	out := gg.ErrStack(gg.Errs{io.EOF, gg.Errs{io.ErrUnexpectedEOF, io.ErrClosedPipe}})
+
+	gtest.Eq(out, `multiple errors:
+
+EOF
+
+multiple errors:
+    unexpected EOF
+    io: read/write on closed pipe`)
+}
+
+func TestErrs_tree_traversal(t *testing.T) {
+	defer gtest.Catch(t)
+
+	src := testErrsNested()
+
+	gtest.True(src.Is(io.EOF))
+	gtest.True(src.Is(io.ErrUnexpectedEOF))
+	gtest.True(src.Is(io.ErrClosedPipe))
+	gtest.True(src.Is(io.ErrNoProgress))
+	gtest.False(src.Is(io.ErrShortWrite))
+
+	gtest.Eq(gg.ErrAs[gg.ErrStr](src), `inner`)
+
+	gtest.Equal(
+		src.Find(func(err error) bool { return err == io.ErrNoProgress }),
+		io.ErrNoProgress,
+	)
+
+	gtest.Equal(
+		gg.ErrFind(
+			errors.Join(io.EOF, gg.Wrap(io.ErrClosedPipe, `wrapped`)),
+			func(err error) bool { return err == io.ErrClosedPipe },
+		),
+		io.ErrClosedPipe,
+	)
+
+	gtest.True(gg.ErrSome(
+		gg.Wrap(errors.Join(io.EOF, gg.Errf(`coded`, testErrCodeRetry)), `outer`),
+		testErrCodeRetry.Match,
+	))
+}
@@ -1,6 +1,9 @@
 package gg
 
-import "unsafe"
+import (
+	"time"
+	"unsafe"
+)
 
 /*
 This file is only included in testing mode (because of `_test.go`)
@@ -11,10 +14,13 @@ func GidSlow() uint64 { return gidSlow() }
 
 func GidWithOverride() uint64 { return getGid() }
 
-func Glss() (out map[uint64]gls) {
-	glss.val.Range(func(key uint64, val gls) bool {
-		MapInit(&out)[key] = val
-		return true
+func Glss() (out map[uint64]GlsInternal) {
+	glss.each(func(gid uint64, src *gls) {
+		val := GlsInternal{}
+		for _, entry := range src.vals {
+			val[entry.key] = entry.val
+		}
+		MapInit(&out)[gid] = val
 	})
 	return
 }
@@ -23,7 +29,7 @@ func GlsCopy(vals ...GlsVal) Gls { return glsCopy(vals...) }
 
 func GlsKey[A any](dyn *DynVar[A]) glsKey { return glsKey(unsafe.Pointer(dyn)) }
 
-type GlsInternal = gls
+type GlsInternal = map[glsKey]any
 
 func DynVarDef[A any](src *DynVar[A]) func() A {
 	defer Lock(&src.lock).Unlock()
@@ -34,3 +40,83 @@ func DynVarVal[A any](src *DynVar[A]) A {
 	defer Lock(&src.lock).Unlock()
 	return src.val
 }
+
+func GlsDel(gid uint64) {
+	glss.del(gid)
+	glsScopes.Delete(gid)
+}
+
+/*
+Replica of the previous GLS storage: one `sync.Map` of mutable maps. Used only
+in benchmarks comparing it with `glss_t`.
+*/
+type GlssLegacy struct {
+	val SyncMap[uint64, map[glsKey]any]
+}
+
+func (self *GlssLegacy) Get(key glsKey) any {
+	val, _ := self.val.Load(getGid())
+	return val[key]
+}
+
+func (self *GlssLegacy) Set(key glsKey, val any) {
+	gid := getGid()
+	tar, _ := self.val.Load(gid)
+	if tar == nil {
+		tar = map[glsKey]any{}
+		self.val.Store(gid, tar)
+	}
+	tar[key] = val
+}
+
+func (self *GlssLegacy) Del(key glsKey) {
+	gid := getGid()
+	tar, _ := self.val.Load(gid)
+	delete(tar, key)
+	if len(tar) <= 0 {
+		self.val.Delete(gid)
+	}
+}
+
+func (self *GlssLegacy) Copy() map[glsKey]any {
+	val, _ := self.val.Load(getGid())
+	return MapClone(val)
+}
+
+func GidCheck(fast func() uint64) error { return gidCheck(fast) }
+
+func GidSetFast(val bool) bool { return gidFastOn.Swap(val) }
+
+// Moves the timestamps of the inner value and error, simulating the passage of time.
+func MemAge[Dur Durationer, Tar any, Ptr IniterPtr[Tar]](src *Mem[Dur, Tar, Ptr], dur time.Duration) {
+	defer Lock(&src.lock).Unlock()
+	src.inst = src.inst.Add(-dur)
+	src.errInst = src.errInst.Add(-dur)
+}
+
+// True if a background refresh is in progress.
+func MemBusy[Dur Durationer, Tar any, Ptr IniterPtr[Tar]](src *Mem[Dur, Tar, Ptr]) bool {
+	defer Lock(&src.lock).Unlock()
+	return src.busy
+}
+
+// Same as `MemAge` for `MemDyn`.
+func MemDynAge[Tar any, Ptr IniterPtr[Tar]](src *MemDyn[Tar, Ptr], dur time.Duration) {
+	MemAge(&src.mem, dur)
+}
+
+// Sets the random factor used for jitter.
+func MemDynSetRnd[Tar any, Ptr IniterPtr[Tar]](src *MemDyn[Tar, Ptr], val float64) {
+	src.once.Do(Nop)
+	src.rnd = val
+}
+
+// Returns the duration after applying the jitter.
+func MemDynDuration[Tar any, Ptr IniterPtr[Tar]](src *MemDyn[Tar, Ptr]) time.Duration {
+	return src.duration()
+}
+
+// Same as `OnceErr`, but uses the given clock.
+func OnceErrClock[A any](clock Clock, dur time.Duration, fun func() (A, error)) func() (A, error) {
+	return onceErr(clock, dur, fun)
+}
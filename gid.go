@@ -1,8 +1,11 @@
 package gg
 
 import (
+	"os"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 /*
@@ -28,8 +31,101 @@ We restrict the fast path to the Go versions whose internals are known to this
 library at the time of writing, because the memory layout of the structures
 we're accessing changes between releases, and we could end up mis-identifying
 arbitrary non-GID memory as a GID.
+
+As an additional safeguard, on startup, the fast path is validated against the
+slow path on several goroutines. If they disagree, this library permanently
+switches to the slow path, and reports the problem via [GidCheckErr].
+
+The fast path can also be disabled without rebuilding, trading speed for
+safety: either by setting the environment variable `GG_GID_SLOW` to any
+non-empty value, which skips the self-check, or by calling [GidUseSlow].
+[GidIsFast] reports which path is used.
 */
-func Gid() uint64 { return gid() }
+func Gid() uint64 {
+	if gidFastOn.Load() {
+		return gidFast()
+	}
+	return gidSlow()
+}
+
+/*
+True if [Gid] uses its fast path, which requires a supported architecture and
+Go version, a successful startup self-check, and no opt-out via `GG_GID_SLOW`
+or [GidUseSlow]. See [Gid] for details.
+*/
+func GidIsFast() bool { return gidFastOn.Load() }
+
+/*
+Returns the error from the startup self-check of the fast path of [Gid], if the
+check has failed. In that case, [Gid] uses the slow path. Returns nil if the
+check has succeeded, or was skipped because the fast path is unsupported or
+disabled via `GG_GID_SLOW`.
+*/
+func GidCheckErr() error { return gidCheckErr }
+
+/*
+Permanently switches [Gid] to the slow path, which parses the output of
+[runtime.Stack] and doesn't rely on Go internals. This is the "pure Go" mode.
+Safe to call concurrently with [Gid]. Has no effect on [GidFunc], which takes
+priority when set. To disable the fast path from the very start of the process,
+set the environment variable `GG_GID_SLOW` to any non-empty value instead.
+*/
+func GidUseSlow() { gidFastOn.Store(false) }
+
+var (
+	gidFastOn   atomic.Bool
+	gidCheckErr error
+)
+
+func init() {
+	if !gidHasFast || os.Getenv(`GG_GID_SLOW`) != `` {
+		return
+	}
+	gidCheckErr = gidCheck(gidFast)
+	gidFastOn.Store(gidCheckErr == nil)
+}
+
+// Amount of goroutines on which `gidCheck` compares the fast and slow paths.
+const gidCheckCount = 4
+
+/*
+Compares the given "fast" goroutine ID function with `gidSlow` on the current
+goroutine and on several new goroutines. Panics are converted to errors.
+*/
+func gidCheck(fast func() uint64) error {
+	var errs [gidCheckCount]error
+	var gro sync.WaitGroup
+
+	gro.Add(len(errs) - 1)
+	for ind := 1; ind < len(errs); ind++ {
+		go gidCheckAsync(&gro, &errs[ind], fast)
+	}
+	errs[0] = gidCheckOne(fast)
+	gro.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return Wrapf(err, `goroutine id self-check failed`)
+		}
+	}
+	return nil
+}
+
+func gidCheckAsync(gro *sync.WaitGroup, out *error, fast func() uint64) {
+	defer gro.Done()
+	*out = gidCheckOne(fast)
+}
+
+func gidCheckOne(fast func() uint64) (err error) {
+	defer Rec(&err)
+
+	exp := gidSlow()
+	act := fast()
+	if act != exp {
+		return Errf(`fast path returned %v, expected %v`, act, exp)
+	}
+	return nil
+}
 
 /*
 Fallback version of [Gid], used on unsupported architectures and in unsupported
@@ -1,4 +1,4 @@
-//go:build (386 || amd64 || arm || arm64 || riscv64 || s390x) && (go1.23 || go1.24 || go1.25)
+//go:build gc && (386 || amd64 || arm || arm64 || riscv64 || s390x) && go1.23 && !go1.26
 
 #include "textflag.h"
 
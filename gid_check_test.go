@@ -0,0 +1,54 @@
+package gg_test
+
+import (
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+func TestGidCheck(t *testing.T) {
+	defer gtest.Catch(t)
+
+	t.Run(`valid`, func(t *testing.T) {
+		defer gtest.Catch(t)
+		gtest.NoErr(gg.GidCheck(gg.GidSlow))
+		gtest.NoErr(gg.GidCheck(gg.Gid))
+	})
+
+	t.Run(`mismatch`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		err := gg.GidCheck(func() uint64 { return 0 })
+		gtest.ErrStr(`goroutine id self-check failed: fast path returned 0, expected `, err)
+	})
+
+	t.Run(`panic`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		err := gg.GidCheck(func() uint64 { panic(gg.ErrStr(`unknown layout`)) })
+		gtest.ErrStr(`goroutine id self-check failed: unknown layout`, err)
+	})
+
+	t.Run(`mismatch_on_other_goroutine`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		gid := gg.GidSlow()
+		err := gg.GidCheck(func() uint64 { return gid })
+		gtest.ErrStr(`goroutine id self-check failed: fast path returned `, err)
+	})
+}
+
+func TestGidIsFast(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.NoErr(gg.GidCheckErr())
+	gtest.Eq(gg.Gid(), gg.GidSlow())
+
+	prev := gg.GidSetFast(gg.GidIsFast())
+	defer gg.GidSetFast(prev)
+
+	gg.GidUseSlow()
+	gtest.False(gg.GidIsFast())
+	gtest.Eq(gg.Gid(), gg.GidSlow())
+}
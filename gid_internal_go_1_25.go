@@ -1,10 +1,12 @@
-//go:build (386 || amd64 || arm || arm64 || riscv64 || s390x) && go1.25 && !go1.26
+//go:build gc && (386 || amd64 || arm || arm64 || riscv64 || s390x) && go1.25 && !go1.26
 
 package gg
 
 import "sync/atomic"
 
-func gid() uint64 { return getg().goid }
+const gidHasFast = true
+
+func gidFast() uint64 { return getg().goid }
 
 func getg() *g
 
@@ -1,5 +1,12 @@
-//go:build (386 || amd64 || arm || arm64 || riscv64 || s390x) && !(go1.23 || go1.24 || go1.25)
+//go:build !(gc && (386 || amd64 || arm || arm64 || riscv64 || s390x) && go1.23 && !go1.26)
 
 package gg
 
-func gid() uint64 { return gidSlow() }
+/*
+Architectures and Go versions without the fast path of [Gid]. The build
+constraint must be the exact negation of the constraints of the files which
+define the fast path, including `gid_asm.s`.
+*/
+const gidHasFast = false
+
+func gidFast() uint64 { return gidSlow() }
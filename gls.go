@@ -12,8 +12,9 @@ up when done, avoiding a memory leak. Accepts any amount of GLS overrides,
 which take effect on the child goroutine. GLS is accessed via [DynVar].
 
 The GLS is snapshotted on the parent goroutine before starting the child.
-The child receives a copy of the parent's GLS. Modification of the parent's
-storage, or termination of the parent, has no effect on the child.
+The child receives the parent's GLS. Because GLS is immutable, this doesn't
+involve copying, and modification of the parent's storage, or termination of
+the parent, has no effect on the child.
 
 Example:
 
@@ -66,6 +67,9 @@ Like [GlsGo] but runs the given function immediately on the current goroutine.
 The provided GLS entry overrides are in effect for the duration of the call;
 previous values are restored before returning. Non-overridden GLS entries
 behave normally.
+
+Overrides for variables using [DynBackendSid] are bound in a new SID scope for
+the duration of the call, and are never visible outside of it.
 */
 func GlsRun(run func(), overrides ...GlsVal) {
 	if run == nil {
@@ -73,6 +77,12 @@ func GlsRun(run func(), overrides ...GlsVal) {
 	}
 	if len(overrides) > 0 {
 		defer glsValsUse(glsValsSwap(overrides))
+
+		sid, ok := sidWith(overrides)
+		if ok {
+			sidRun(sid, run)
+			return
+		}
 	}
 	run()
 }
@@ -84,6 +94,12 @@ func GlsRun1[A any](run func(A), val A, overrides ...GlsVal) {
 	}
 	if len(overrides) > 0 {
 		defer glsValsUse(glsValsSwap(overrides))
+
+		sid, ok := sidWith(overrides)
+		if ok {
+			sidRun(sid, func() { run(val) })
+			return
+		}
 	}
 	run(val)
 }
@@ -98,8 +114,8 @@ which are started by external code you don't control, but invoke your own
 callback. Normally, you should start goroutines via [GlsGo] or [GlsGo1], which
 automatically propagate GLS from parents to children, and ensure cleanup.
 
-Invoking this with no arguments is equivalent to [GlsClear]. The latter is
-provided because it can be passed to functions which only take `func()`.
+Invoking this with no arguments clears the GLS like [GlsClear], but keeps
+the scopes bound via [GlsBind].
 
 Returns the previous GLS; if GLS was empty, returns a zero value.
 
@@ -127,19 +143,8 @@ At the beginning of a goroutine, a valid alternative is to use a deferred
 func GlsSet(vals ...GlsVal) Gls {
 	gid := getGid()
 	prev := glss.get(gid)
-	size := len(vals)
-
-	if size <= 0 {
-		glss.del(gid)
-	} else {
-		gls := make(gls, size)
-		for _, val := range vals {
-			if !val.del {
-				gls[val.key] = val.val
-			}
-		}
-		glss.set(gid, gls)
-	}
+
+	glss.set(gid, glsFrom(vals))
 	return Gls{val: prev}
 }
 
@@ -155,21 +160,28 @@ Overrides are created with [DynVar.With] and [DynVar.WithClear].
 Unnecessary when using [GlsGo] and [GlsGo1], which automatically
 copy GLS from parent to child goroutines.
 
+Overrides for variables using [DynBackendSid] are ignored.
+
 The order of entries is undefined and may vary between calls.
 */
 func GlsSnap(overrides ...GlsVal) []GlsVal {
 	gls := glss.get(getGid())
 	size := len(overrides)
 	keys := make(Set[glsKey], size)
-	out := make([]GlsVal, 0, size+len(gls))
+	out := make([]GlsVal, 0, size+gls.len())
 
 	for _, val := range overrides {
+		if val.sid {
+			continue
+		}
 		out = append(out, val)
 		keys.Add(val.key)
 	}
-	for key, val := range gls {
-		if !keys.Has(key) {
-			out = append(out, GlsVal{key: key, val: val})
+	if gls != nil {
+		for _, val := range gls.vals {
+			if !keys.Has(val.key) {
+				out = append(out, GlsVal{key: val.key, val: val.val})
+			}
 		}
 	}
 	return out
@@ -199,21 +211,33 @@ GLS cleanup in testing is optional, but can be done like this:
 	t.Cleanup(gg.GlsClear)
 	b.Cleanup(gg.GlsClear)
 
-This function is equivalent to calling [GlsSet] with no arguments. The latter
-is more flexible. The former is provided because it can be passed to functions
-which only take `func()`, like in the example above.
+This function is similar to calling [GlsSet] with no arguments, but also
+discards the scopes bound via [GlsBind] which haven't been restored. It can be
+passed to functions which only take `func()`, like in the example above.
 */
-func GlsClear() { glss.del(getGid()) }
+func GlsClear() {
+	gid := getGid()
+	glss.del(gid)
+	glsScopes.Delete(gid)
+}
 
 /*
 An opaque structure representing GLS (goroutine-local storage) of a single
-goroutine. Returned by [GlsSet]. May contain mutable state. Permanently moving
-this to another goroutine is valid, but sharing this between goroutines is
-invalid and may lead to silent logical errors and occasional panics.
+goroutine. Returned by [GlsSet]. Immutable: modifications of GLS replace the
+storage of the current goroutine, without affecting existing [Gls] values.
+Using the same [Gls] on multiple goroutines is valid.
 
 GLS is accessed via dynamic variables: [DynVar].
 */
-type Gls struct{ val gls }
+type Gls struct {
+	val *gls
+
+	/**
+	Values of the SID scope, if any, which must be bound in a new scope when
+	running a function with this GLS. See `glsCopy` and `sidRun`.
+	*/
+	sid *gls
+}
 
 /*
 Replaces the current goroutine's GLS with this snapshot. If the snapshot is
@@ -224,12 +248,7 @@ and can be used to restore that state. See [GlsSet] for usage examples.
 func (self Gls) Use() Gls {
 	gid := getGid()
 	prev := glss.get(gid)
-	gls := self.val
-	if len(gls) > 0 {
-		glss.set(gid, gls)
-	} else {
-		glss.del(gid)
-	}
+	glss.set(gid, self.val)
 	return Gls{val: prev}
 }
 
@@ -255,6 +274,7 @@ type GlsVal struct {
 	key glsKey
 	val any
 	del bool
+	sid bool
 }
 
 /*
@@ -265,21 +285,16 @@ state of that entry in the current GLS, which can be chained into deferred
 func (self GlsVal) Use() GlsVal {
 	// SYNC[gls_val_use].
 
-	gid := getGid()
-	key := self.key
-
-	if !self.del {
-		gls := glss.getOrMake(gid)
-		prev, had := gls[key]
-		gls[key] = self.val
-		return GlsVal{key: key, val: prev, del: !had}
+	if self.sid {
+		return self.useSid()
 	}
 
+	gid := getGid()
 	gls := glss.get(gid)
-	prev, had := gls[key]
-	delete(gls, key)
-	if len(gls) <= 0 {
-		glss.del(gid)
+	prev := gls.prev(self.key)
+
+	if !self.del || !prev.del {
+		glss.set(gid, gls.use(self))
 	}
-	return GlsVal{key: key, val: prev, del: !had}
+	return prev
 }
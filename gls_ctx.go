@@ -0,0 +1,123 @@
+package gg
+
+import "context"
+
+/*
+This file implements a bridge between GLS (goroutine-local storage) and
+[context.Context], for code which mixes dynamic variables ([DynVar]) with
+libraries which propagate contexts but spawn goroutines with the plain `go`
+keyword.
+*/
+
+/*
+Returns a child context carrying a snapshot of the current goroutine's GLS,
+like [GlsSnap], with any amount of overrides. If the given context already
+carries a GLS snapshot, the new snapshot replaces it; the current GLS is the
+source of truth. If the given context is nil, [context.Background] is used.
+
+The snapshot can be restored on another goroutine via [GlsSetCtx], or read by
+individual variables via [DynVar.GetCtx] and [DynVar.GotCtx]. Example:
+
+	ctx = gg.GlsSnapCtx(ctx)
+
+	someLibrary.Spawn(ctx, func(ctx context.Context) {
+		defer gg.GlsSetCtx(ctx).Use()
+		// ... GLS is available here.
+	})
+*/
+func GlsSnapCtx(ctx context.Context, overrides ...GlsVal) context.Context {
+	return context.WithValue(ctxOr(ctx), glsCtxKey{}, glsCopy(overrides...).val)
+}
+
+/*
+Returns the GLS snapshot carried by the given context, created by [GlsSnapCtx]
+or [DynVar.SetCtx], as a slice suitable for [GlsSet], [GlsGo], [GlsRun].
+If there's no snapshot, returns nil. The order of entries is undefined.
+*/
+func GlsFromCtx(ctx context.Context) []GlsVal {
+	src := glsCtxGet(ctx)
+	if src.len() <= 0 {
+		return nil
+	}
+
+	out := make([]GlsVal, 0, src.len())
+	for _, val := range src.vals {
+		out = append(out, GlsVal{key: val.key, val: val.val})
+	}
+	return out
+}
+
+/*
+Replaces the current goroutine's GLS with the snapshot carried by the given
+context, like [GlsSet]. If the context carries no snapshot, this clears the
+GLS, which is appropriate for goroutines started by external code, where any
+previous GLS would be stale. Returns the previous GLS, which should be chained
+into deferred [Gls.Use]:
+
+	defer gg.GlsSetCtx(ctx).Use()
+*/
+func GlsSetCtx(ctx context.Context) Gls {
+	return Gls{val: glsCtxGet(ctx)}.Use()
+}
+
+/*
+Returns a child context whose GLS snapshot is the snapshot of the given context,
+if any, with this variable set to the given value. Unlike [DynVar.Set], this
+doesn't modify the current goroutine's GLS. If the given context is nil,
+[context.Background] is used.
+*/
+func (self *DynVar[A]) SetCtx(ctx context.Context, val A) context.Context {
+	ctx = ctxOr(ctx)
+	return context.WithValue(ctx, glsCtxKey{}, glsCtxGet(ctx).with(self.key(), val))
+}
+
+/*
+Returns the value of this variable from the current goroutine's GLS, if set.
+Otherwise returns the value from the GLS snapshot carried by the given context,
+if set. Does _not_ fall back on the default value. Useful on goroutines which
+were spawned by code that propagates contexts but not GLS.
+*/
+func (self *DynVar[A]) GotCtx(ctx context.Context) (A, bool) {
+	val, ok := self.got()
+	if ok {
+		return val, ok
+	}
+	src, _ := glsCtxGet(ctx).get(self.key())
+	val, ok = src.(A)
+	return val, ok
+}
+
+/*
+Like [DynVar.Get], but before falling back on the default value, tries the
+GLS snapshot carried by the given context. See [DynVar.GotCtx].
+*/
+func (self *DynVar[A]) GetCtx(ctx context.Context) A {
+	val, ok := self.got()
+	if ok {
+		return val
+	}
+
+	src, _ := glsCtxGet(ctx).get(self.key())
+	val, ok = src.(A)
+	if ok {
+		return val
+	}
+	return self.getDef()
+}
+
+type glsCtxKey struct{}
+
+func glsCtxGet(ctx context.Context) *gls {
+	if ctx == nil {
+		return nil
+	}
+	out, _ := ctx.Value(glsCtxKey{}).(*gls)
+	return out
+}
+
+func ctxOr(ctx context.Context) context.Context {
+	if ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
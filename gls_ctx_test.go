@@ -0,0 +1,94 @@
+package gg_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+func TestGlsSnapCtx(t *testing.T) {
+	defer gtest.Catch(t)
+	t.Cleanup(gg.GlsClear)
+
+	ctx := context.Background()
+	gtest.Zero(gg.GlsFromCtx(ctx))
+	gtest.Zero(gg.GlsFromCtx(nil))
+
+	defer DYN_NUM.Set(10).Use()
+	defer DYN_MOD.Set(SomeModel{Id: 20}).Use()
+
+	ctx = gg.GlsSnapCtx(ctx, DYN_MOD.WithClear())
+	gtest.Len(gg.GlsFromCtx(ctx), 1)
+
+	// Later modifications of the GLS don't affect the snapshot.
+	defer DYN_NUM.Set(30).Use()
+
+	// Plain `go` doesn't inherit GLS, but the context carries it.
+	goWait(func() (_ struct{}) {
+		gtest.Zero(DYN_NUM.Get())
+
+		defer gg.GlsSetCtx(ctx).Use()
+		gtest.Eq(DYN_NUM.Get(), 10)
+		gtest.Zero(DYN_MOD.Get())
+		return
+	})
+
+	goWait(func() (_ struct{}) {
+		defer gg.GlsSet(gg.GlsFromCtx(ctx)...).Use()
+		gtest.Eq(DYN_NUM.Get(), 10)
+		return
+	})
+
+	// Restoring from a context without a snapshot clears the GLS.
+	goWait(func() (_ struct{}) {
+		defer DYN_NUM.Set(40).Use()
+
+		prev := gg.GlsSetCtx(context.Background())
+		gtest.Zero(DYN_NUM.Get())
+
+		prev.Use()
+		gtest.Eq(DYN_NUM.Get(), 40)
+		return
+	})
+
+	gtest.Eq(DYN_NUM.Get(), 30)
+}
+
+func TestDynVar_ctx(t *testing.T) {
+	defer gtest.Catch(t)
+	t.Cleanup(gg.GlsClear)
+
+	ctx := DYN_NUM.SetCtx(nil, 10)
+	ctx = DYN_PTR.SetCtx(ctx, nil)
+
+	// The context's value is not visible to plain `Get`.
+	gtest.Zero(DYN_NUM.Get())
+
+	gtest.Eq(DYN_NUM.GetCtx(ctx), 10)
+	gtest.Eq(gg.Tuple2(DYN_NUM.GotCtx(ctx)), gg.Tuple2(10, true))
+	gtest.Eq(gg.Tuple2(DYN_NUM.GotCtx(context.Background())), gg.Tuple2(0, false))
+	gtest.Eq(gg.Tuple2(DYN_NUM.GotCtx(nil)), gg.Tuple2(0, false))
+
+	// Explicit nil in the context takes priority over the default.
+	gtest.Zero(DYN_PTR.GetCtx(ctx))
+	gtest.NotZero(DYN_PTR.GetCtx(context.Background()))
+
+	// The goroutine's own value takes priority over the context.
+	defer DYN_NUM.Set(20).Use()
+	gtest.Eq(DYN_NUM.GetCtx(ctx), 20)
+
+	goWait(func() (_ struct{}) {
+		gtest.Eq(DYN_NUM.GetCtx(ctx), 10)
+		return
+	})
+
+	// Setting in a child context doesn't affect the parent.
+	child := DYN_NUM.SetCtx(ctx, 30)
+	goWait(func() (_ struct{}) {
+		gtest.Eq(DYN_NUM.GetCtx(child), 30)
+		gtest.Eq(DYN_NUM.GetCtx(ctx), 10)
+		return
+	})
+}
@@ -0,0 +1,235 @@
+package gg
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+/*
+This file implements diagnostics for GLS (goroutine-local storage), mostly for
+detecting leaks: entries in the central registry which are never deleted
+because user code forgot to defer [GlsVal.Use], [Gls.Use], [GlsScope.Use] or
+[GlsClear]. The central registry includes the scopes bound via [GlsBind].
+*/
+
+/*
+Describes the GLS of one goroutine at the time of the call. Returned by
+[GlsDump] and [GlsLeaks]. The values are shallow copies of the GLS entries.
+`.Scopes` is the amount of scopes bound via [GlsBind] and not yet restored.
+*/
+type GlsInfo struct {
+	Gid    uint64
+	Live   bool
+	Vals   []GlsInfoVal
+	Scopes int
+}
+
+/*
+Describes one GLS entry. `.Var` is the identifier of the [DynVar] which owns
+the entry, see [DynVar.Id].
+*/
+type GlsInfoVal struct {
+	Var uintptr
+	Val any
+}
+
+/*
+Implement `fmt.Stringer` for debug printing. Variables are printed as
+addresses, values as their types.
+*/
+func (self GlsInfo) String() string {
+	var buf Buf
+	buf.AppendString(`goroutine `)
+	buf.AppendUint64(self.Gid)
+	if !self.Live {
+		buf.AppendString(` (exited)`)
+	}
+	buf.AppendString(`:`)
+
+	for _, val := range self.Vals {
+		buf.AppendString(` `)
+		buf.AppendString(fmt.Sprintf(`%#x=%T`, val.Var, val.Val))
+	}
+	if self.Scopes > 0 {
+		buf.AppendString(` scopes=`)
+		buf.AppendInt(self.Scopes)
+	}
+	return buf.String()
+}
+
+/*
+Returns the identifier used for this variable in GLS, which is its address.
+Can be compared with [GlsInfoVal.Var].
+*/
+func (self *DynVar[A]) Id() uintptr { return uintptr(self.key()) }
+
+/*
+Returns the amount of goroutines which currently have GLS entries in the
+central registry, regardless of whether those goroutines are still running.
+Cheap enough for periodic monitoring. In a program without leaks, this tends
+to be proportional to the amount of goroutines which are using GLS.
+*/
+func GlsCount() (out int) {
+	glss.each(func(uint64, *gls) { out++ })
+	glsScopes.Range(func(gid uint64, _ *glsFrame) bool {
+		if glss.get(gid) == nil {
+			out++
+		}
+		return true
+	})
+	return
+}
+
+/*
+Returns the IDs of goroutines which currently have GLS entries in the central
+registry, in ascending order, regardless of whether those goroutines are still
+running. Unlike [GlsDump], this doesn't read the entries and is always safe.
+*/
+func GlsGids() []uint64 {
+	return Map(glssSnap(), func(val glssEntry) uint64 { return val.gid })
+}
+
+/*
+Returns a description of every GLS in the central registry, ordered by
+goroutine ID. Determining which goroutines are still running requires a stack
+dump of all goroutines via [runtime.Stack], which briefly stops the world; this
+is meant for debugging and testing, not for hot paths.
+
+The GLS of each goroutine is immutable, so reading the GLS of running
+goroutines is safe, but they may modify their GLS concurrently; the result is
+a snapshot.
+*/
+func GlsDump() []GlsInfo {
+	src := glssSnap()
+	if len(src) <= 0 {
+		return nil
+	}
+
+	live := goroutineIds()
+	out := make([]GlsInfo, 0, len(src))
+	for _, val := range src {
+		out = append(out, GlsInfo{
+			Gid:    val.gid,
+			Live:   live.Has(val.gid),
+			Vals:   glsInfoVals(val.gls),
+			Scopes: val.scope.len(),
+		})
+	}
+	return out
+}
+
+/*
+Returns a description of every GLS whose goroutine has exited, ordered by
+goroutine ID. Such entries are never deleted and indicate missing cleanup; see
+[GlsSet] and [GlsClear]. Like [GlsDump], this requires a stack dump of all
+goroutines, and is meant for debugging and testing.
+*/
+func GlsLeaks() []GlsInfo {
+	src := glssSnap()
+	if len(src) <= 0 {
+		return nil
+	}
+
+	live := goroutineIds()
+	var out []GlsInfo
+
+	for _, val := range src {
+		/**
+		A goroutine may have cleaned up its GLS and exited between `glssSnap` and
+		`goroutineIds`, in which case its GLS is no longer registered, and the GLS
+		we've read is stale.
+		*/
+		if live.Has(val.gid) || !glssHas(val.gid) {
+			continue
+		}
+		out = append(out, GlsInfo{
+			Gid:    val.gid,
+			Vals:   glsInfoVals(val.gls),
+			Scopes: val.scope.len(),
+		})
+	}
+	return out
+}
+
+type glssEntry struct {
+	gid   uint64
+	gls   *gls
+	scope *glsFrame
+}
+
+// Includes goroutines which have only scopes and no values.
+func glssSnap() (out []glssEntry) {
+	var index map[uint64]int
+
+	glss.each(func(gid uint64, src *gls) {
+		MapInit(&index)[gid] = len(out)
+		out = append(out, glssEntry{gid: gid, gls: src})
+	})
+
+	glsScopes.Range(func(gid uint64, top *glsFrame) bool {
+		ind, ok := index[gid]
+		if ok {
+			out[ind].scope = top
+		} else {
+			out = append(out, glssEntry{gid: gid, scope: top})
+		}
+		return true
+	})
+
+	sort.Slice(out, func(one, two int) bool { return out[one].gid < out[two].gid })
+	return
+}
+
+func glssHas(gid uint64) bool {
+	if glss.get(gid) != nil {
+		return true
+	}
+	_, ok := glsScopes.Load(gid)
+	return ok
+}
+
+// The entries of `gls` are already sorted by key.
+func glsInfoVals(src *gls) []GlsInfoVal {
+	if src == nil {
+		return nil
+	}
+	out := make([]GlsInfoVal, 0, len(src.vals))
+	for _, val := range src.vals {
+		out = append(out, GlsInfoVal{Var: uintptr(val.key), Val: val.val})
+	}
+	return out
+}
+
+// IDs of all running goroutines, parsed from a full stack dump.
+func goroutineIds() Set[uint64] {
+	buf := make([]byte, 1<<16)
+	for {
+		size := runtime.Stack(buf, true)
+		if size < len(buf) {
+			buf = buf[:size]
+			break
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+
+	const pre = `goroutine `
+	out := Set[uint64]{}
+
+	for _, line := range SplitLines(ToString(buf)) {
+		if !strings.HasPrefix(line, pre) {
+			continue
+		}
+
+		line = line[len(pre):]
+		var ind int
+		for ind < len(line) && isDigit(line[ind]) {
+			ind++
+		}
+		if ind > 0 {
+			out.Add(ParseTo[uint64](line[:ind]))
+		}
+	}
+	return out
+}
@@ -0,0 +1,191 @@
+package gg_test
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+func TestGlsDump(t *testing.T) {
+	defer gtest.Catch(t)
+	t.Cleanup(gg.GlsClear)
+
+	gid := gg.Gid()
+	gtest.NotHas(gg.GlsGids(), gid)
+
+	count := gg.GlsCount()
+
+	defer DYN_NUM.Set(10).Use()
+	defer DYN_MOD.Set(SomeModel{Id: 20}).Use()
+
+	gtest.Eq(gg.GlsCount(), count+1)
+	gtest.Has(gg.GlsGids(), gid)
+
+	info := gg.Find(gg.GlsDump(), func(val gg.GlsInfo) bool { return val.Gid == gid })
+	gtest.Eq(info.Gid, gid)
+	gtest.True(info.Live)
+	gtest.EqualSet(
+		gg.Map(info.Vals, func(val gg.GlsInfoVal) uintptr { return val.Var }),
+		[]uintptr{DYN_NUM.Id(), DYN_MOD.Id()},
+	)
+	gtest.Has(
+		gg.Map(info.Vals, func(val gg.GlsInfoVal) any { return val.Val }),
+		any(10),
+	)
+
+	gtest.TextHas(info.String(), fmt.Sprintf(`goroutine %v: `, gid))
+	gtest.TextHas(info.String(), `=int`)
+	gtest.NotTextHas(info.String(), `exited`)
+}
+
+func TestGlsLeaks(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gid := glsLeak()
+	defer gg.GlsDel(gid)
+
+	leak := waitGlsLeak(gid)
+	gtest.Eq(leak.Gid, gid)
+	gtest.False(leak.Live)
+	gtest.Equal(leak.Vals, []gg.GlsInfoVal{{Var: DYN_NUM.Id(), Val: 10}})
+	gtest.TextHas(leak.String(), `(exited)`)
+
+	// Properly cleaned-up goroutines don't leak.
+	count := len(gg.GlsLeaks())
+	goWait(func() (_ struct{}) {
+		defer DYN_NUM.Set(10).Use()
+		return
+	})
+	gtest.Len(gg.GlsLeaks(), count)
+}
+
+func TestGlsNoLeaks(t *testing.T) {
+	defer gtest.Catch(t)
+
+	t.Run(`ok`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var tb testTB
+		gtest.GlsNoLeaks(&tb)
+		defer DYN_NUM.Set(10).Use()
+		goWait(func() (_ struct{}) {
+			defer gg.GlsClear()
+			DYN_NUM.Set(20)
+			return
+		})
+
+		gg.GlsClear()
+		tb.RunCleanups()
+		gtest.Zero(tb.Errs)
+	})
+
+	t.Run(`leak_other`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var tb testTB
+		gtest.GlsNoLeaks(&tb)
+
+		gid := glsLeak()
+		defer gg.GlsDel(gid)
+		waitGlsLeak(gid)
+
+		tb.RunCleanups()
+		gtest.Len(tb.Errs, 1)
+		gtest.TextHas(tb.Errs[0], fmt.Sprintf(`leaked GLS: goroutine %v (exited)`, gid))
+	})
+
+	t.Run(`leak_own`, func(t *testing.T) {
+		defer gtest.Catch(t)
+		t.Cleanup(gg.GlsClear)
+
+		var tb testTB
+		gtest.GlsNoLeaks(&tb)
+		DYN_NUM.Set(10)
+
+		tb.RunCleanups()
+		gtest.Len(tb.Errs, 1)
+		gtest.TextHas(tb.Errs[0], `was not cleaned up`)
+	})
+}
+
+func TestGlsLeaks_scopes(t *testing.T) {
+	defer gtest.Catch(t)
+
+	count := gg.GlsCount()
+
+	// Values are cleared, but the scope is never restored.
+	gid := goWait(func() uint64 {
+		gg.GlsBind(DYN_NUM.With(10))
+		gg.GlsSet()
+		return gg.Gid()
+	})
+	defer gg.GlsDel(gid)
+
+	gtest.Eq(gg.GlsCount(), count+1)
+	gtest.Has(gg.GlsGids(), gid)
+
+	leak := waitGlsLeak(gid)
+	gtest.Zero(leak.Vals)
+	gtest.Eq(leak.Scopes, 1)
+	gtest.TextHas(leak.String(), `(exited): scopes=1`)
+
+	// Goroutines started via `GlsGo` discard their scopes when done.
+	var tb testTB
+	gtest.GlsNoLeaks(&tb)
+
+	done := make(chan struct{})
+	gg.GlsGo(func() {
+		defer close(done)
+		gg.GlsBind(DYN_NUM.With(10))
+	})
+	<-done
+
+	for gg.GlsCount() > count+1 {
+		runtime.Gosched()
+	}
+	tb.RunCleanups()
+	gtest.Zero(tb.Errs)
+}
+
+// Sets a GLS value on a new goroutine without cleanup, returning its ID.
+func glsLeak() uint64 {
+	return goWait(func() uint64 {
+		DYN_NUM.Set(10)
+		return gg.Gid()
+	})
+}
+
+// The goroutine may still be running for a short while after `glsLeak`.
+func waitGlsLeak(gid uint64) gg.GlsInfo {
+	for range gg.Span(1024) {
+		out := gg.Find(gg.GlsLeaks(), func(val gg.GlsInfo) bool { return val.Gid == gid })
+		if out.Gid != 0 {
+			return out
+		}
+		runtime.Gosched()
+	}
+	panic(gg.Errf(`GLS of goroutine %v was not detected as leaked`, gid))
+}
+
+type testTB struct {
+	testing.TB
+	Errs     []string
+	Cleanups []func()
+}
+
+func (*testTB) Helper() {}
+
+func (self *testTB) Errorf(pat string, arg ...any) {
+	self.Errs = append(self.Errs, fmt.Sprintf(pat, arg...))
+}
+
+func (self *testTB) Cleanup(fun func()) { self.Cleanups = append(self.Cleanups, fun) }
+
+func (self *testTB) RunCleanups() {
+	for ind := len(self.Cleanups) - 1; ind >= 0; ind-- {
+		self.Cleanups[ind]()
+	}
+}
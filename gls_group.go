@@ -0,0 +1,247 @@
+package gg
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+Errgroup-like tool which runs functions concurrently, where each goroutine
+inherits the caller's GLS (goroutine-local storage), like [GlsGo]. Must be
+created via [NewGlsGroup]. Features:
+
+  - The GLS is snapshotted when calling [GlsGroup.Go] or [GlsGroup.GoErr], with
+    optional per-task overrides, see [DynVar.With].
+  - Panics are caught and converted to errors with stack traces, like in
+    [ConcCatch].
+  - The amount of concurrently running functions may be limited.
+  - The first error cancels the group's context, which is passed to every
+    function. [GlsGroup.Wait] returns that error.
+
+Example:
+
+	group := gg.NewGlsGroup(ctx, 8)
+	for _, val := range vals {
+		group.Go(func(ctx context.Context) { process(ctx, val) }, SOME_VAR.With(val))
+	}
+	gg.Try(group.Wait())
+
+Also see [GlsPool], which reuses a fixed set of goroutines.
+*/
+type GlsGroup struct {
+	glsRunner
+	sem chan struct{}
+	gro sync.WaitGroup
+}
+
+/*
+Creates a [GlsGroup] whose context is derived from the given context. If the
+limit is positive, it's the maximum amount of concurrently running functions;
+when the limit is reached, [GlsGroup.Go] blocks until a function completes.
+If the given context is nil, [context.Background] is used.
+*/
+func NewGlsGroup(ctx context.Context, limit int) *GlsGroup {
+	out := new(GlsGroup)
+	out.init(ctx)
+	if limit > 0 {
+		out.sem = make(chan struct{}, limit)
+	}
+	return out
+}
+
+/*
+Runs the given function on a new goroutine which inherits the current GLS with
+the given overrides. If the function panics, the panic is converted to an error
+which cancels the group. If the function is nil, does nothing.
+*/
+func (self *GlsGroup) Go(fun func(context.Context), overrides ...GlsVal) {
+	if fun != nil {
+		self.goTask(glsTask{gls: glsCopy(overrides...), fun: fun})
+	}
+}
+
+/*
+Like [GlsGroup.Go] but for functions which return errors. A non-nil error
+cancels the group, just like a panic. If the function is nil, does nothing.
+*/
+func (self *GlsGroup) GoErr(fun func(context.Context) error, overrides ...GlsVal) {
+	if fun != nil {
+		self.goTask(glsTask{gls: glsCopy(overrides...), funErr: fun})
+	}
+}
+
+/*
+Waits for all functions to complete, cancels the group's context, and returns
+the first error, if any, with a stack trace connecting the trace of the failed
+goroutine with the trace of the caller.
+*/
+func (self *GlsGroup) Wait() error {
+	self.gro.Wait()
+	return WrapTracedAt(self.done(), 1)
+}
+
+func (self *GlsGroup) goTask(task glsTask) {
+	if self.sem != nil {
+		self.sem <- struct{}{}
+	}
+	self.gro.Add(1)
+	go self.run(task)
+}
+
+func (self *GlsGroup) run(task glsTask) {
+	defer self.gro.Done()
+	if self.sem != nil {
+		defer self.release()
+	}
+
+	task.gls.Use()
+	defer GlsClear()
+	self.exec(task)
+}
+
+func (self *GlsGroup) release() { <-self.sem }
+
+/*
+Bounded worker pool where each task runs with the GLS (goroutine-local storage)
+of the caller which submitted it, like in [GlsGroup]. Must be created via
+[NewGlsPool]. Unlike [GlsGroup], tasks are executed by a fixed set of
+long-lived goroutines. Each worker replaces its GLS with the task's GLS before
+running the task, and clears its GLS afterwards, so values never bleed between
+tasks, even when a task modifies its GLS without cleanup.
+
+Error handling is the same as in [GlsGroup]: the first error or panic cancels
+the pool's context, and is returned by [GlsPool.Wait].
+
+After calling [GlsPool.Wait], the pool must not be used again.
+*/
+type GlsPool struct {
+	glsRunner
+	tasks chan glsTask
+	gro   sync.WaitGroup
+	once  sync.Once
+}
+
+/*
+Creates a [GlsPool] with the given amount of workers, at least 1, whose context
+is derived from the given context. If the given context is nil,
+[context.Background] is used.
+*/
+func NewGlsPool(ctx context.Context, size int) *GlsPool {
+	out := new(GlsPool)
+	out.init(ctx)
+	out.tasks = make(chan glsTask)
+
+	size = MaxPrim2(size, 1)
+	out.gro.Add(size)
+	for range Iter(size) {
+		go out.work()
+	}
+	return out
+}
+
+/*
+Submits the given function for execution with the current GLS and the given
+overrides. Blocks until a worker is available. If the function is nil, does
+nothing.
+*/
+func (self *GlsPool) Go(fun func(context.Context), overrides ...GlsVal) {
+	if fun != nil {
+		self.tasks <- glsTask{gls: glsCopy(overrides...), fun: fun}
+	}
+}
+
+// Like [GlsPool.Go] but for functions which return errors.
+func (self *GlsPool) GoErr(fun func(context.Context) error, overrides ...GlsVal) {
+	if fun != nil {
+		self.tasks <- glsTask{gls: glsCopy(overrides...), funErr: fun}
+	}
+}
+
+/*
+Stops accepting tasks, waits for the submitted tasks to complete, cancels the
+pool's context, and returns the first error, if any. See [GlsGroup.Wait].
+Idempotent.
+*/
+func (self *GlsPool) Wait() error {
+	self.once.Do(self.close)
+	self.gro.Wait()
+	return WrapTracedAt(self.done(), 1)
+}
+
+func (self *GlsPool) close() { close(self.tasks) }
+
+func (self *GlsPool) work() {
+	defer self.gro.Done()
+	defer GlsClear()
+
+	for task := range self.tasks {
+		self.runTask(task)
+	}
+}
+
+func (self *GlsPool) runTask(task glsTask) {
+	task.gls.Use()
+	defer GlsClear()
+	self.exec(task)
+}
+
+// State shared by `GlsGroup` and `GlsPool`.
+type glsRunner struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	lock   sync.Mutex
+	err    error
+}
+
+func (self *glsRunner) init(ctx context.Context) {
+	self.ctx, self.cancel = context.WithCancel(ctxOr(ctx))
+}
+
+/*
+Returns the context passed to every function. It's canceled on the first error,
+or when waiting is done.
+*/
+func (self *glsRunner) Ctx() context.Context { return self.ctx }
+
+func (self *glsRunner) exec(task glsTask) {
+	if task.gls.sid != nil {
+		sid := task.gls.sid
+		task.gls.sid = nil
+		sidRun(sid, func() { self.exec(task) })
+		return
+	}
+
+	err := task.run(self.ctx)
+	if err != nil {
+		self.fail(err)
+	}
+}
+
+func (self *glsRunner) fail(err error) {
+	defer Lock(&self.lock).Unlock()
+	if self.err == nil {
+		self.err = err
+		self.cancel()
+	}
+}
+
+func (self *glsRunner) done() error {
+	self.cancel()
+	defer Lock(&self.lock).Unlock()
+	return self.err
+}
+
+type glsTask struct {
+	gls    Gls
+	fun    func(context.Context)
+	funErr func(context.Context) error
+}
+
+func (self glsTask) run(ctx context.Context) (err error) {
+	defer Rec(&err)
+	if self.fun != nil {
+		self.fun(ctx)
+		return nil
+	}
+	return ErrTracedAt(self.funErr(ctx), 1)
+}
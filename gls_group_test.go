@@ -0,0 +1,183 @@
+package gg_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+func TestGlsGroup(t *testing.T) {
+	defer gtest.Catch(t)
+	gtest.GlsNoLeaks(t)
+	t.Cleanup(gg.GlsClear)
+
+	defer DYN_NUM.Set(10).Use()
+
+	group := gg.NewGlsGroup(nil, 0)
+	var nums [3]int
+
+	group.Go(func(context.Context) { nums[0] = DYN_NUM.Get() })
+	group.Go(func(context.Context) { nums[1] = DYN_NUM.Get() }, DYN_NUM.With(20))
+	group.GoErr(func(context.Context) error {
+		nums[2] = DYN_NUM.Get()
+		DYN_NUM.Set(30)
+		return nil
+	}, DYN_NUM.WithClear())
+	group.Go(nil)
+	group.GoErr(nil)
+
+	gtest.NoErr(group.Wait())
+	gtest.Eq(nums, [3]int{10, 20, 0})
+	gtest.Eq(DYN_NUM.Get(), 10)
+	gtest.ErrIs(group.Ctx().Err(), context.Canceled)
+}
+
+func TestGlsGroup_limit(t *testing.T) {
+	defer gtest.Catch(t)
+
+	group := gg.NewGlsGroup(context.Background(), 2)
+	var peak testConcPeak
+
+	for range gg.Iter(8) {
+		group.Go(func(context.Context) { peak.Run(time.Millisecond) })
+	}
+
+	gtest.NoErr(group.Wait())
+	gtest.Eq(peak.peak.Load(), 2)
+}
+
+func TestGlsGroup_error(t *testing.T) {
+	defer gtest.Catch(t)
+
+	t.Run(`panic`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		group := gg.NewGlsGroup(context.Background(), 0)
+		var canceled atomic.Bool
+
+		group.Go(func(ctx context.Context) {
+			<-ctx.Done()
+			canceled.Store(true)
+			panic(ctx.Err())
+		})
+		group.Go(func(context.Context) { panic(io.EOF) })
+
+		err := group.Wait()
+		gtest.ErrIs(err, io.EOF)
+		gtest.False(errors.Is(err, context.Canceled))
+		gtest.True(canceled.Load())
+		gtest.True(gg.IsErrTraced(errors.Unwrap(err)), `must preserve the goroutine's trace`)
+	})
+
+	t.Run(`error`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		group := gg.NewGlsGroup(context.Background(), 1)
+		group.GoErr(func(context.Context) error { return io.EOF })
+
+		err := group.Wait()
+		gtest.ErrIs(err, io.EOF)
+		gtest.True(gg.IsErrTraced(errors.Unwrap(err)))
+	})
+
+	t.Run(`parent_canceled`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		group := gg.NewGlsGroup(ctx, 0)
+		group.Go(func(ctx context.Context) { gg.ErrOk(ctx) })
+		gtest.ErrIs(group.Wait(), context.Canceled)
+	})
+}
+
+func TestGlsPool(t *testing.T) {
+	defer gtest.Catch(t)
+	gtest.GlsNoLeaks(t)
+	t.Cleanup(gg.GlsClear)
+
+	defer DYN_NUM.Set(10).Use()
+
+	// A single worker runs the tasks in order, on the same goroutine.
+	pool := gg.NewGlsPool(nil, 0)
+	var gids []uint64
+	var nums []int
+
+	task := func(context.Context) {
+		gids = append(gids, gg.Gid())
+		nums = append(nums, DYN_NUM.Get())
+		_, ok := DYN_MOD.Got()
+		gtest.False(ok, `GLS must not bleed between tasks`)
+
+		// Modifications without cleanup.
+		DYN_NUM.Set(-1)
+		DYN_MOD.Set(SomeModel{})
+	}
+
+	pool.Go(task)
+	pool.Go(task, DYN_NUM.With(20))
+	pool.GoErr(func(ctx context.Context) error {
+		task(ctx)
+		return nil
+	}, DYN_NUM.WithClear())
+	pool.Go(nil)
+
+	gtest.NoErr(pool.Wait())
+	gtest.NoErr(pool.Wait())
+
+	gtest.Equal(nums, []int{10, 20, 0})
+	gtest.Len(gids, 3)
+	gtest.Eq(gids[0], gids[1])
+	gtest.Eq(gids[1], gids[2])
+	gtest.Eq(DYN_NUM.Get(), 10)
+}
+
+func TestGlsPool_error(t *testing.T) {
+	defer gtest.Catch(t)
+
+	pool := gg.NewGlsPool(context.Background(), 4)
+	var canceled atomic.Int64
+
+	for range gg.Iter(3) {
+		pool.Go(func(ctx context.Context) {
+			<-ctx.Done()
+			canceled.Add(1)
+		})
+	}
+	pool.GoErr(func(context.Context) error { return io.EOF })
+
+	err := pool.Wait()
+	gtest.ErrIs(err, io.EOF)
+	gtest.Eq(canceled.Load(), 3)
+	gtest.ErrIs(pool.Ctx().Err(), context.Canceled)
+}
+
+func TestGlsPool_scopes(t *testing.T) {
+	defer gtest.Catch(t)
+	gtest.GlsNoLeaks(t)
+
+	pool := gg.NewGlsPool(nil, 0)
+	var bindings [][]gg.DynBinding[int]
+
+	task := func(context.Context) {
+		bindings = append(bindings, DYN_NUM.Bindings())
+
+		// Scope without restore.
+		gg.GlsBind(DYN_NUM.With(10))
+	}
+
+	pool.Go(task)
+	pool.Go(task)
+	gtest.NoErr(pool.Wait())
+
+	gtest.Len(bindings, 2)
+	gtest.Zero(bindings[0])
+	gtest.Zero(bindings[1], `scopes must not bleed between tasks`)
+}
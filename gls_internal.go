@@ -1,49 +1,271 @@
 package gg
 
+import "sync/atomic"
+
 var glss glss_t
 
 /*
 Short for "goroutine-local storage storage". Keys are goroutine IDs.
 
-See `BenchmarkDynVar_with_minor_concurrency` for some perf notes.
+The storage is split into shards by goroutine ID, to reduce contention when
+many goroutines concurrently create and delete their GLS. Each goroutine which
+has GLS owns a `glsCell`, which holds an immutable `gls`. Replacing the GLS of
+a goroutine which already has one is a single atomic store into its own cell,
+and doesn't touch the shard. Shards are modified only when a goroutine's GLS
+is created or deleted.
+
+See `BenchmarkDynVar_with_minor_concurrency` and `BenchmarkGlss_*` for some
+perf notes.
+*/
+type glss_t struct{ shards [glssShardCount]glssShard }
+
+/*
+Must be a power of 2. Goroutine IDs are allocated sequentially, so concurrently
+running goroutines tend to be evenly spread among the shards.
+*/
+const glssShardCount = 64
+
+/*
+The padding prevents false sharing between adjacent shards, which would
+otherwise defeat the purpose of sharding.
 */
-type glss_t struct{ val SyncMap[uint64, gls] }
+type glssShard struct {
+	val SyncMap[uint64, *glsCell]
+	_   [64]byte
+}
+
+/*
+Owned by one goroutine, which is the only one to modify it. Other goroutines
+may read it for diagnostics, see `GlsDump`.
+*/
+type glsCell struct{ val atomic.Pointer[gls] }
+
+func (self *glss_t) shard(gid uint64) *glssShard {
+	return &self.shards[gid&(glssShardCount-1)]
+}
 
-func (self *glss_t) get(gid uint64) gls {
-	out, _ := self.val.Load(gid)
+func (self *glss_t) cell(gid uint64) *glsCell {
+	out, _ := self.shard(gid).val.Load(gid)
 	return out
 }
 
+func (self *glss_t) get(gid uint64) *gls {
+	cell := self.cell(gid)
+	if cell == nil {
+		return nil
+	}
+	return cell.val.Load()
+}
+
 /*
-The apparent race condition between load / check / make / store is benign,
-because each goroutine creates and accesses only its own GLS.
+If the given GLS is empty, deletes the goroutine's GLS. The apparent race
+condition between load / check / store is benign, because each goroutine
+creates and modifies only its own GLS.
 */
-func (self *glss_t) getOrMake(gid uint64) gls {
-	out := self.get(gid)
-	if out != nil {
-		return out
+func (self *glss_t) set(gid uint64, val *gls) {
+	if val.len() <= 0 {
+		self.del(gid)
+		return
 	}
 
-	out = make(gls, 1)
-	self.val.Store(gid, out)
-	return out
+	shard := self.shard(gid)
+	cell, _ := shard.val.Load(gid)
+	if cell == nil {
+		cell = new(glsCell)
+		cell.val.Store(val)
+		shard.val.Store(gid, cell)
+		return
+	}
+	cell.val.Store(val)
 }
 
-func (self *glss_t) set(gid uint64, val gls) { self.val.Store(gid, val) }
+func (self *glss_t) del(gid uint64) { self.shard(gid).val.Delete(gid) }
 
-func (self *glss_t) del(gid uint64) { self.val.Delete(gid) }
+func (self *glss_t) each(fun func(uint64, *gls)) {
+	for ind := range self.shards {
+		self.shards[ind].val.Range(func(gid uint64, cell *glsCell) bool {
+			fun(gid, cell.val.Load())
+			return true
+		})
+	}
+}
 
 /*
-Short for "goroutine-local storage".
+Short for "goroutine-local storage". Immutable, persistent map of [DynVar]
+values: every modification returns a new `gls`, sharing nothing mutable with
+the original. This allows to snapshot the GLS in O(1) by copying a pointer,
+and to share snapshots between any amount of goroutines. A nil pointer is a
+valid empty `gls`.
 
-We create, read, and mutate each `gls` only on its own goroutine.
+The entries are sorted by key. Modifications copy the entries, which is O(N),
+but in practice N is small: the amount of dynamic variables set on a single
+goroutine rarely exceeds a few dozen, and for such sizes copying a contiguous
+array is faster than updating a tree, and lookups via binary search are faster
+than lookups in a hash map.
 
 At the time of writing, our GLS API makes it possible for user code to create
 more than one `gls` for the same goroutine, for example by repeatedly calling
 [GlsSet] with different sets of values. As a result, most code should access
 `gls` from `glss` without caching it; [Gls] is the only exception.
 */
-type gls = map[glsKey]any
+type gls struct {
+	vals []glsEntry
+
+	/**
+	Inline storage for the most common case of one entry, which saves an
+	allocation. See `newGls`.
+	*/
+	one [1]glsEntry
+}
+
+func newGls(size int) *gls {
+	out := new(gls)
+	if size == 1 {
+		out.vals = out.one[:]
+	} else {
+		out.vals = make([]glsEntry, size)
+	}
+	return out
+}
+
+type glsEntry struct {
+	key glsKey
+	val any
+}
+
+func (self *gls) len() int {
+	if self == nil {
+		return 0
+	}
+	return len(self.vals)
+}
+
+// Binary search. Returns the index of the key, or where it would be inserted.
+func (self *gls) index(key glsKey) (int, bool) {
+	if self == nil {
+		return 0, false
+	}
+
+	vals := self.vals
+	low, high := 0, len(vals)
+	for low < high {
+		mid := int(uint(low+high) >> 1)
+		if vals[mid].key < key {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	return low, low < len(vals) && vals[low].key == key
+}
+
+func (self *gls) get(key glsKey) (any, bool) {
+	ind, ok := self.index(key)
+	if ok {
+		return self.vals[ind].val, true
+	}
+	return nil, false
+}
+
+func (self *gls) with(key glsKey, val any) *gls {
+	ind, ok := self.index(key)
+	size := self.len()
+
+	if ok {
+		out := newGls(size)
+		copy(out.vals, self.vals)
+		out.vals[ind].val = val
+		return out
+	}
+
+	out := newGls(size + 1)
+	if size > 0 {
+		copy(out.vals, self.vals[:ind])
+		copy(out.vals[ind+1:], self.vals[ind:])
+	}
+	out.vals[ind] = glsEntry{key, val}
+	return out
+}
+
+func (self *gls) without(key glsKey) *gls {
+	ind, ok := self.index(key)
+	if !ok {
+		return self
+	}
+
+	size := self.len()
+	if size <= 1 {
+		return nil
+	}
+
+	out := newGls(size - 1)
+	copy(out.vals, self.vals[:ind])
+	copy(out.vals[ind:], self.vals[ind+1:])
+	return out
+}
+
+/*
+Caution: unlike `GlsVal.Use`, this doesn't modify `glss`, and merely returns
+the modified `gls`.
+
+SYNC[gls_val_use].
+*/
+func (self *gls) use(val GlsVal) *gls {
+	if val.del {
+		return self.without(val.key)
+	}
+	return self.with(val.key, val.val)
+}
+
+/*
+Creates a `gls` from the given entries, ignoring deletions and entries of
+SID-backed variables. For duplicate keys, the last entry wins.
+*/
+func glsFrom(src []GlsVal) *gls {
+	var size int
+	for _, val := range src {
+		if !val.del && !val.sid {
+			size++
+		}
+	}
+	if size <= 0 {
+		return nil
+	}
+
+	out := newGls(size)
+	vals := out.vals[:0]
+	for _, val := range src {
+		if !val.del && !val.sid {
+			vals = append(vals, glsEntry{val.key, val.val})
+		}
+	}
+
+	// Stable insertion sort, which is optimal for the small sizes we expect.
+	for ind := 1; ind < len(vals); ind++ {
+		for prev := ind; prev > 0 && vals[prev].key < vals[prev-1].key; prev-- {
+			vals[prev], vals[prev-1] = vals[prev-1], vals[prev]
+		}
+	}
+
+	// Deduplicate in place, keeping the last of each run of equal keys.
+	var ind int
+	for _, val := range vals {
+		if ind > 0 && vals[ind-1].key == val.key {
+			vals[ind-1] = val
+		} else {
+			vals[ind] = val
+			ind++
+		}
+	}
+	out.vals = vals[:ind]
+	return out
+}
+
+// Returns a `GlsVal` which restores the current state of the given entry.
+func (self *gls) prev(key glsKey) GlsVal {
+	val, ok := self.get(key)
+	return GlsVal{key: key, val: val, del: !ok}
+}
 
 // GLS keys are [DynVar] pointers.
 type glsKey uintptr
@@ -70,92 +292,102 @@ liveness via [runtime.AddCleanup]. Something like:
 	func glsOnVarCleanup(key *glsKey) {
 		key.live.Store(false)
 
-		// And here we would have to delete entries keyed by `key.ptr`
-		// from every GLS in `glss`.
+		// And here we would have to replace every GLS in `glss`
+		// with a version without entries keyed by `key.ptr`.
 	}
 
 When using a [GlsVal], we would check liveness and skip / ignore "dead" vals,
-which is easy enough. But deleting the entries from every `gls` would require
-additional synchronization at the level of each `gls`, since the finalizer runs
-in its own goroutine, and slow everything down for the sake of a use case which
-is probably not even real.
+which is easy enough. But replacing the GLS of other goroutines would require
+additional synchronization at the level of each `glsCell`, since the finalizer
+runs in its own goroutine, and slow everything down for the sake of a use case
+which is probably not even real.
 */
 
 /*
-Like [GlsSnap] but creates a new map directly. Slightly more efficient than
-combining [GlsSnap] with [GlsSet]. Private because the difference is not large
-enough to justify bloating the library's interface, and because this is easy to
-misuse by passing the resulting [Gls] to multiple child goroutines.
-*/
-func glsCopy(overrides ...GlsVal) Gls {
-	src := glss.get(getGid())
-	out := make(gls, len(src)+len(overrides))
+Like [GlsSnap] but returns the current GLS directly, applying the overrides, if
+any. Without overrides, this is O(1) because `gls` is immutable.
 
-	for key, val := range src {
-		out[key] = val
-	}
+Also captures the values of the current SID scope, if any, with the overrides
+of SID-backed variables. See [DynBackendSid].
+*/
+func glsCopy(overrides ...GlsVal) (out Gls) {
+	out.val = glss.get(getGid())
 	for _, val := range overrides {
-		glsValUse(out, val)
+		if !val.sid {
+			out.val = out.val.use(val)
+		}
+	}
+
+	sid, ok := sidWith(overrides)
+	if !ok {
+		sid = sidLoad()
 	}
-	return Gls{val: out}
+	out.sid = sid
+	return
 }
 
-// Must be passed into deferred `glsValsUse`.
-func glsValsSwap(vals []GlsVal) (_ uint64, _ gls, _ []GlsVal) {
+/*
+Must be passed into deferred `glsValsUse`. Returns entries which restore the
+previous state, in reverse order, which is necessary when the given entries
+have duplicate keys.
+*/
+func glsValsSwap(vals []GlsVal) (_ uint64, _ []GlsVal) {
 	// SYNC[gls_vals_swap_nop].
 	if len(vals) <= 0 {
 		return
 	}
 
 	gid := getGid()
-	gls := glss.getOrMake(gid)
-	out := make([]GlsVal, 0, len(vals))
+	gls := glss.get(gid)
+	out := make([]GlsVal, len(vals))
 
-	for _, val := range vals {
-		key := val.key
-		prev, had := gls[key]
-		out = append(out, GlsVal{key: key, val: prev, del: !had})
-		glsValUse(gls, val)
+	for ind, val := range vals {
+		// Entries of SID-backed variables are restored by `glsValsUse` as no-ops.
+		if val.sid {
+			out[len(out)-ind-1] = val
+			continue
+		}
+		out[len(out)-ind-1] = gls.prev(val.key)
+		gls = gls.use(val)
 	}
 
-	return gid, gls, out
+	glss.set(gid, gls)
+	return gid, out
 }
 
-func glsValsUse(gid uint64, gls gls, vals []GlsVal) {
+func glsValsUse(gid uint64, vals []GlsVal) {
 	// SYNC[gls_vals_swap_nop].
 	if len(vals) <= 0 {
 		return
 	}
-	for _, val := range vals {
-		glsValUse(gls, val)
-	}
-	if len(gls) <= 0 {
-		glss.del(gid)
-	}
-}
 
-/*
-Caution: in most cases, the caller must also check the remaining GLS size
-and delete an empty GLS from the GLSS.
-
-SYNC[gls_val_use].
-*/
-func glsValUse(gls gls, val GlsVal) {
-	if val.del {
-		delete(gls, val.key)
-	} else {
-		gls[val.key] = val.val
+	gls := glss.get(gid)
+	for _, val := range vals {
+		if !val.sid {
+			gls = gls.use(val)
+		}
 	}
+	glss.set(gid, gls)
 }
 
 func withGls(gls Gls, run func()) {
 	gls.Use()
 	defer GlsClear()
+
+	if gls.sid != nil {
+		sidRun(gls.sid, run)
+		return
+	}
 	run()
 }
 
 func withGls1[A any](gls Gls, run func(A), val A) {
 	gls.Use()
 	defer GlsClear()
+
+	if gls.sid != nil {
+		sidRun(gls.sid, func() { run(val) })
+		return
+	}
 	run(val)
 }
@@ -0,0 +1,182 @@
+package gg
+
+import "runtime"
+
+/*
+Binds any amount of dynamic variables on the current goroutine at once, with
+one restore. The values are usually created via [DynVar.With] and
+[DynVar.WithClear]. Returns a [GlsScope] whose [GlsScope.Use] must be deferred
+to restore the previous state of every variable:
+
+	defer gg.GlsBind(REQ_ID.With(id), USER.With(user)).Use()
+
+All bindings take effect at once: the GLS of the current goroutine is replaced
+in one step. For duplicate variables, the last value wins.
+
+Scopes may be nested, and must be restored in reverse order of binding, which
+is guaranteed when using `defer`. Restoring out of order panics, instead of
+silently corrupting the GLS. The bindings of active scopes can be inspected via
+[DynVar.Bindings].
+
+Active scopes are local to the goroutine which bound them. Child goroutines
+spawned via [GlsGo] and similar functions inherit the bound values, but not the
+scopes: [DynVar.Bindings] doesn't list them there, and they can't be restored
+there. Scopes are also excluded from GLS snapshots such as [GlsSnap].
+
+Values of variables using [DynBackendSid] are ignored; bind them via [GlsRun].
+*/
+func GlsBind(vals ...GlsVal) GlsScope {
+	gid := getGid()
+	gls := glss.get(gid)
+
+	prev, _ := glsScopes.Load(gid)
+	frame := &glsFrame{
+		prev:    prev,
+		vals:    Clone(vals),
+		restore: make([]GlsVal, len(vals)),
+		site:    callerAt(1),
+	}
+
+	for ind, val := range vals {
+		if val.sid {
+			frame.restore[len(vals)-ind-1] = val
+			continue
+		}
+		frame.restore[len(vals)-ind-1] = gls.prev(val.key)
+		gls = gls.use(val)
+	}
+
+	glss.set(gid, gls)
+	glsScopes.Store(gid, frame)
+	return GlsScope{frame}
+}
+
+/*
+Opaque structure representing a set of bindings of dynamic variables created by
+[GlsBind]. Must be restored via deferred [GlsScope.Use]. The zero value is an
+empty scope whose [GlsScope.Use] does nothing.
+*/
+type GlsScope struct{ frame *glsFrame }
+
+/*
+Restores the previous state of every variable bound by this scope. Must be
+called on the same goroutine where the scope was bound, and only when this
+scope is the innermost active scope.
+Otherwise panics with an error describing the mismatch, without modifying the
+GLS. Calling this twice is also a mismatch.
+*/
+func (self GlsScope) Use() {
+	frame := self.frame
+	if frame == nil {
+		return
+	}
+
+	gid := getGid()
+	top, _ := glsScopes.Load(gid)
+	if top != frame {
+		panic(errGlsScopeMismatch(frame, top))
+	}
+
+	gls := glss.get(gid)
+
+	for _, val := range frame.restore {
+		if !val.sid {
+			gls = gls.use(val)
+		}
+	}
+
+	glss.set(gid, gls)
+	if frame.prev == nil {
+		glsScopes.Delete(gid)
+	} else {
+		glsScopes.Store(gid, frame.prev)
+	}
+}
+
+/*
+Describes a binding of a [DynVar] by an active [GlsScope]. Returned by
+[DynVar.Bindings]. If `.Has` is false, the scope cleared the variable via
+[DynVar.WithClear]. `.Site` is the caller of [GlsBind].
+*/
+type DynBinding[A any] struct {
+	Val  A
+	Has  bool
+	Site Caller
+}
+
+/*
+Returns the bindings of this variable by the active scopes on the current
+goroutine, created via [GlsBind], from outermost to innermost. Useful for
+debugging nested overrides. Modifications via [DynVar.Set] and other functions
+other than [GlsBind] are not included. For the current value, use
+[DynVar.Got].
+*/
+func (self *DynVar[A]) Bindings() []DynBinding[A] {
+	key := self.key()
+	top, _ := glsScopes.Load(getGid())
+	var out []DynBinding[A]
+
+	for frame := top; frame != nil; frame = frame.prev {
+		for ind := len(frame.vals) - 1; ind >= 0; ind-- {
+			val := frame.vals[ind]
+			if val.key == key {
+				tar, _ := val.val.(A)
+				out = append(out, DynBinding[A]{tar, !val.del, frame.site})
+				break
+			}
+		}
+	}
+
+	Reverse(out)
+	return out
+}
+
+/*
+Stacks of active scopes, keyed by goroutine ID. Stored separately from `glss`,
+so that scopes never appear among the values of dynamic variables, and don't
+leak into GLS snapshots or other goroutines. An entry is deleted when the
+outermost scope of its goroutine is restored, or by [GlsClear].
+*/
+var glsScopes SyncMap[uint64, *glsFrame]
+
+type glsFrame struct {
+	prev    *glsFrame
+	vals    []GlsVal
+	restore []GlsVal
+	site    Caller
+}
+
+func (self *glsFrame) len() (out int) {
+	for ; self != nil; self = self.prev {
+		out++
+	}
+	return
+}
+
+func (self *glsFrame) has(val *glsFrame) bool {
+	for ; self != nil; self = self.prev {
+		if self == val {
+			return true
+		}
+	}
+	return false
+}
+
+func errGlsScopeMismatch(frame, top *glsFrame) Err {
+	if !top.has(frame) {
+		return Errf(
+			`mismatched restore of GLS scope bound at %v: the scope is not active on this goroutine; it was already restored, or bound on another goroutine`,
+			frame.site,
+		)
+	}
+	return Errf(
+		`mismatched restore of GLS scope bound at %v: the innermost active scope, bound at %v, must be restored first`,
+		frame.site, top.site,
+	)
+}
+
+func callerAt(skip int) Caller {
+	var buf [1]uintptr
+	runtime.Callers(skip+2, buf[:])
+	return Caller(buf[0])
+}
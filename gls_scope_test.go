@@ -0,0 +1,142 @@
+package gg_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+func TestGlsBind(t *testing.T) {
+	defer gtest.Catch(t)
+	gtest.GlsNoLeaks(t)
+
+	gg.GlsScope{}.Use()
+
+	func() {
+		defer DYN_NUM.Set(10).Use()
+
+		func() {
+			defer gg.GlsBind(DYN_NUM.With(20), DYN_MOD.With(SomeModel{Id: 30})).Use()
+
+			gtest.Eq(DYN_NUM.Get(), 20)
+			gtest.Eq(DYN_MOD.Get(), SomeModel{Id: 30})
+
+			func() {
+				defer gg.GlsBind(DYN_NUM.WithClear(), DYN_NUM.With(40)).Use()
+				gtest.Eq(DYN_NUM.Get(), 40)
+				gtest.Eq(DYN_MOD.Get(), SomeModel{Id: 30})
+
+				func() {
+					defer gg.GlsBind(DYN_NUM.WithClear()).Use()
+					gtest.Eq(gg.Tuple2(DYN_NUM.Got()), gg.Tuple2(0, false))
+				}()
+
+				gtest.Eq(DYN_NUM.Get(), 40)
+			}()
+
+			gtest.Eq(DYN_NUM.Get(), 20)
+
+			gg.GlsRun(func() {
+				gtest.Eq(DYN_NUM.Get(), 20)
+				gtest.Len(DYN_NUM.Bindings(), 1)
+			})
+
+			// Child goroutines inherit the bound values, but not the scope.
+			out := make(chan int, 1)
+			gg.GlsGo(func() { out <- DYN_NUM.Get() + len(DYN_NUM.Bindings()) })
+			gtest.Eq(<-out, 20)
+		}()
+
+		gtest.Eq(DYN_NUM.Get(), 10)
+		gtest.Eq(gg.Tuple2(DYN_MOD.Got()), gg.Tuple2(SomeModel{}, false))
+	}()
+
+	gtest.Zero(gg.Glss()[gg.Gid()])
+}
+
+func TestDynVar_Bindings(t *testing.T) {
+	defer gtest.Catch(t)
+	t.Cleanup(gg.GlsClear)
+
+	gtest.Zero(DYN_NUM.Bindings())
+
+	defer DYN_NUM.Set(10).Use()
+	gtest.Zero(DYN_NUM.Bindings())
+
+	defer gg.GlsBind(DYN_NUM.With(20)).Use()
+	defer gg.GlsBind(DYN_MOD.With(SomeModel{})).Use()
+	defer gg.GlsBind(DYN_NUM.With(30), DYN_NUM.WithClear()).Use()
+
+	vals := DYN_NUM.Bindings()
+	gtest.Len(vals, 2)
+
+	gtest.Eq(vals[0].Val, 20)
+	gtest.True(vals[0].Has)
+	gtest.Eq(vals[0].Site.Frame().Line, 68)
+
+	gtest.Zero(vals[1].Val)
+	gtest.False(vals[1].Has)
+	gtest.Eq(vals[1].Site.Frame().Line, 70)
+
+	gtest.Len(DYN_MOD.Bindings(), 1)
+}
+
+func TestGlsScope_mismatch(t *testing.T) {
+	defer gtest.Catch(t)
+	t.Cleanup(gg.GlsClear)
+
+	outer := gg.GlsBind(DYN_NUM.With(10))
+	inner := gg.GlsBind(DYN_NUM.With(20))
+
+	gtest.PanicStr(`the innermost active scope, bound at`, outer.Use)
+
+	// The failed restore must not modify the GLS.
+	gtest.Eq(DYN_NUM.Get(), 20)
+	gtest.Len(DYN_NUM.Bindings(), 2)
+
+	inner.Use()
+	gtest.Eq(DYN_NUM.Get(), 10)
+	gtest.PanicStr(`the scope is not active on this goroutine`, inner.Use)
+
+	outer.Use()
+	gtest.Zero(DYN_NUM.Get())
+	gtest.Zero(gg.Glss()[gg.Gid()])
+
+	goWait(func() (_ struct{}) {
+		gtest.PanicStr(`the scope is not active on this goroutine`, outer.Use)
+		return
+	})
+}
+
+func TestGlsBind_snapshots(t *testing.T) {
+	defer gtest.Catch(t)
+	gtest.GlsNoLeaks(t)
+
+	defer gg.GlsBind(DYN_NUM.With(10)).Use()
+
+	// The scope is not a variable and must not appear among variables.
+	gtest.Equal(gg.Glss()[gg.Gid()], gg.GlsInternal{gg.GlsKey(DYN_NUM): 10})
+	gtest.Len(gg.GlsSnap(), 1)
+	gtest.Len(gg.GlsFromCtx(gg.GlsSnapCtx(context.Background())), 1)
+
+	info := gg.Find(gg.GlsDump(), func(val gg.GlsInfo) bool { return val.Gid == gg.Gid() })
+	gtest.Len(info.Vals, 1)
+
+	// The scope must not be restorable from a goroutine which received a
+	// snapshot of the GLS.
+	scope := gg.GlsBind(DYN_MOD.With(SomeModel{Id: 20}))
+	gls := gg.GlsSnap()
+	gtest.Len(gls, 2)
+
+	goWait(func() (_ struct{}) {
+		defer gg.GlsSet(gls...).Use()
+		gtest.Eq(DYN_MOD.Get(), SomeModel{Id: 20})
+		gtest.Zero(DYN_MOD.Bindings())
+		gtest.PanicStr(`the scope is not active on this goroutine`, scope.Use)
+		return
+	})
+
+	scope.Use()
+}
@@ -2,6 +2,7 @@ package gg_test
 
 import (
 	"context"
+	"sync"
 	"testing"
 
 	"github.com/mitranim/gg"
@@ -256,3 +257,172 @@ func BenchmarkGls_Use(b *testing.B) {
 		gg.Nop1(gls.Use())
 	}
 }
+
+/*
+The following benchmarks compare the current GLS storage with a replica of the
+previous one, `gg.GlssLegacy`: a single `sync.Map` of mutable maps, where
+child snapshots copy the parent's map.
+*/
+
+func BenchmarkGlss_Get_parallel(b *testing.B) {
+	defer gtest.Catch(b)
+
+	b.RunParallel(func(pb *testing.PB) {
+		defer gg.GlsClear()
+		defer DYN_NUM.Set(123).Use()
+
+		for pb.Next() {
+			gg.Nop1(DYN_NUM.Get())
+		}
+	})
+}
+
+func BenchmarkGlss_Get_parallel_legacy(b *testing.B) {
+	defer gtest.Catch(b)
+
+	var glss gg.GlssLegacy
+	key := gg.GlsKey(DYN_NUM)
+
+	b.RunParallel(func(pb *testing.PB) {
+		defer glss.Del(key)
+		glss.Set(key, 123)
+
+		for pb.Next() {
+			gg.Nop1(glss.Get(key))
+		}
+	})
+}
+
+func BenchmarkGlss_Set_parallel(b *testing.B) {
+	defer gtest.Catch(b)
+
+	b.RunParallel(func(pb *testing.PB) {
+		defer gg.GlsClear()
+
+		for pb.Next() {
+			DYN_NUM.Set(123).Use()
+		}
+	})
+}
+
+func BenchmarkGlss_Set_parallel_legacy(b *testing.B) {
+	defer gtest.Catch(b)
+
+	var glss gg.GlssLegacy
+	key := gg.GlsKey(DYN_NUM)
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			glss.Set(key, 123)
+			glss.Del(key)
+		}
+	})
+}
+
+func BenchmarkGlss_child_snapshot(b *testing.B) {
+	defer gtest.Catch(b)
+	b.Cleanup(gg.GlsClear)
+
+	for _, val := range benchGlsVars {
+		val.Set(123)
+	}
+
+	b.ResetTimer()
+	for ind := 0; ind < b.N; ind++ {
+		gg.Nop1(gg.GlsCopy())
+	}
+}
+
+func BenchmarkGlss_child_snapshot_legacy(b *testing.B) {
+	defer gtest.Catch(b)
+
+	var glss gg.GlssLegacy
+	for _, val := range benchGlsVars {
+		glss.Set(gg.GlsKey(val), 123)
+	}
+
+	b.ResetTimer()
+	for ind := 0; ind < b.N; ind++ {
+		gg.Nop1(glss.Copy())
+	}
+}
+
+func BenchmarkGlss_many_goroutines(b *testing.B) {
+	defer gtest.Catch(b)
+	b.Cleanup(gg.GlsClear)
+	defer DYN_NUM.Set(123).Use()
+
+	var gro sync.WaitGroup
+
+	for ind := 0; ind < b.N; ind++ {
+		for range gg.Iter(1024) {
+			gro.Add(1)
+
+			gg.GlsGo(func() {
+				defer gro.Done()
+
+				for range gg.Iter(32) {
+					gg.Nop1(DYN_NUM.Get())
+				}
+			})
+		}
+		gro.Wait()
+	}
+}
+
+func BenchmarkGlss_many_goroutines_legacy(b *testing.B) {
+	defer gtest.Catch(b)
+
+	var glss gg.GlssLegacy
+	var gro sync.WaitGroup
+	key := gg.GlsKey(DYN_NUM)
+
+	glss.Set(key, 123)
+	defer glss.Del(key)
+
+	for ind := 0; ind < b.N; ind++ {
+		for range gg.Iter(1024) {
+			gro.Add(1)
+			gls := glss.Copy()
+
+			go func() {
+				defer gro.Done()
+				glss.Set(key, gls[key])
+				defer glss.Del(key)
+
+				for range gg.Iter(32) {
+					gg.Nop1(glss.Get(key))
+				}
+			}()
+		}
+		gro.Wait()
+	}
+}
+
+var benchGlsVars = gg.Map(gg.Span(16), func(int) *gg.DynVar[int] {
+	return gg.NewDynVar[int](nil)
+})
+
+func TestGlsSet_duplicates(t *testing.T) {
+	defer gtest.Catch(t)
+	t.Cleanup(gg.GlsClear)
+
+	gg.GlsSet(DYN_NUM.With(10), DYN_MOD.With(SomeModel{Id: 20}), DYN_NUM.With(30), DYN_PTR.WithClear())
+
+	gtest.Equal(gg.Glss(), map[uint64]gg.GlsInternal{
+		gg.Gid(): {
+			gg.GlsKey(DYN_NUM): 30,
+			gg.GlsKey(DYN_MOD): SomeModel{Id: 20},
+		},
+	})
+
+	gg.GlsRun(func() {
+		gtest.Eq(DYN_NUM.Get(), 50)
+	}, DYN_NUM.With(40), DYN_NUM.With(50))
+
+	// Restoring duplicate overrides must restore the original value.
+	gtest.Eq(DYN_NUM.Get(), 30)
+
+	gg.GlsSet(DYN_NUM.WithClear())
+	gtest.Zero(gg.Glss())
+}
@@ -68,10 +68,62 @@ func Catch(t testing.TB) {
 	t.Helper()
 	val := gg.AnyErrTracedAt(recover(), 1)
 	if val != nil {
+		if CatchSnippet {
+			t.Fatal(gg.ErrStackSnippet(val))
+		}
 		t.Fatalf(`%+v`, val)
 	}
 }
 
+/*
+When true, `Catch` follows the stack of the failure with snippets of source
+code around each frame of app code, see `gg.ErrStackSnippet`. Disabled by
+default. Useful in CI, for example:
+
+	func TestMain(m *testing.M) {
+		gtest.CatchSnippet = os.Getenv(`CI`) != ``
+		os.Exit(m.Run())
+	}
+*/
+var CatchSnippet = false
+
+/*
+Registers a cleanup which fails the test if GLS (goroutine-local storage)
+entries leaked during the test. Leaks are GLS entries of goroutines which have
+exited, see `gg.GlsLeaks`, and GLS entries which remain on the test's own
+goroutine when the cleanup runs, since that goroutine is about to exit. GLS
+entries of goroutines which already had them before this call are ignored.
+
+Cleanups run in reverse order. Call this at the start of a test, before
+registering other cleanups which clear GLS, such as `t.Cleanup(gg.GlsClear)`.
+Usage:
+
+	func TestSomething(t *testing.T) {
+		defer gtest.Catch(t)
+		gtest.GlsNoLeaks(t)
+		// ...
+	}
+*/
+func GlsNoLeaks(t testing.TB) {
+	t.Helper()
+	prev := gg.SetOf(gg.GlsGids()...)
+
+	t.Cleanup(func() {
+		t.Helper()
+
+		for _, val := range gg.GlsLeaks() {
+			if !prev.Has(val.Gid) {
+				t.Errorf(`leaked GLS: %v`, val)
+			}
+		}
+
+		gid := gg.Gid()
+		if !prev.Has(gid) && gg.Has(gg.GlsGids(), gid) {
+			t.Errorf(`GLS of test goroutine %v was not cleaned up`, gid)
+		}
+	})
+}
+
 /*
 Asserts that the input is `true`, or fails the test, printing the optional
 additional messages and the stack trace.
@@ -29,16 +29,28 @@ func errAppendInner(buf Buf, err error) Buf {
 
 func errAppendTraceIndentWithNewline(buf Buf, trace Trace) Buf {
 	if trace.IsNotEmpty() {
+		size := len(buf)
 		buf.AppendNewline()
-		return errAppendTraceIndent(buf, trace)
+		buf = errAppendTraceIndent(buf, trace)
+
+		// All frames may be hidden by `TraceSkipLang` or `TraceFilter`.
+		if len(buf) == size+1 {
+			return buf[:size]
+		}
 	}
 	return buf
 }
 
 func errAppendTraceIndent(buf Buf, trace Trace) Buf {
 	if trace.IsNotEmpty() {
+		size := len(buf)
 		buf.AppendString(`trace:`)
+		prev := len(buf)
 		buf = trace.AppendIndentTo(buf, 1)
+
+		if len(buf) == prev {
+			return buf[:size]
+		}
 	}
 	return buf
 }
@@ -3,6 +3,7 @@ package gg
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 /*
@@ -56,6 +57,75 @@ func Once[A any](fun func() A) func() A {
 	}
 }
 
+/*
+Like [Once], but for functions which may fail. Returned errors and panics are
+converted to errors with stack traces, and are remembered for the given
+duration: calls which were waiting for the failed call, and calls within the
+duration after it, return the same error, each with its own stack trace added,
+without calling the function. The first call after the duration retries
+automatically. If the duration is 0 or negative, errors are shared only with
+concurrent callers. After the first success, the value is remembered forever,
+and the function is released. Calls do not overlap. For a similar tool with
+expiration of successful values, see [Mem] and [DurErr].
+*/
+func OnceErr[A any](dur time.Duration, fun func() (A, error)) func() (A, error) {
+	return onceErr(ClockReal{}, dur, fun)
+}
+
+// Implementation of [OnceErr] with a configurable clock, used in tests.
+func onceErr[A any](clock Clock, dur time.Duration, fun func() (A, error)) func() (A, error) {
+	if fun == nil {
+		return func() (_ A, _ error) { return }
+	}
+
+	var done atomic.Bool
+	var fails atomic.Uint64
+	var lock sync.Mutex
+	var val A
+	var fail error
+	var failInst time.Time
+
+	/**
+	The given count of failures is observed before waiting for the lock. If it
+	has changed, the caller was waiting for the failed call.
+	*/
+	create := func(prev uint64) (A, error) {
+		defer Lock(&lock).Unlock()
+		if done.Load() {
+			return val, nil
+		}
+
+		if fail != nil && (fails.Load() != prev || (dur > 0 && !failInst.Add(dur).Before(clock.Now()))) {
+			return Zero[A](), fail
+		}
+
+		out, err := onceErrCall(fun)
+		if err != nil {
+			fail, failInst = err, clock.Now()
+			fails.Add(1)
+			return Zero[A](), err
+		}
+
+		val, fail, fun = out, nil, nil
+		done.Store(true)
+		return val, nil
+	}
+
+	return func() (A, error) {
+		if done.Load() {
+			return val, nil
+		}
+		out, err := create(fails.Load())
+		return out, WrapTracedAt(err, 1)
+	}
+}
+
+func onceErrCall[A any](fun func() (A, error)) (out A, err error) {
+	defer RecN(&err, 1)
+	out, err = fun()
+	return out, ErrTracedAt(err, 1)
+}
+
 /*
 Creates [Lazy] with the given function. Deprecated in favor of [Once],
 which is easier to use.
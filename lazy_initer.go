@@ -19,6 +19,10 @@ is performed exactly once. Access is synchronized. All methods are
 concurrency-safe. Designed to be embeddable. A zero value is ready to use. When
 using this as a struct field, you don't need to explicitly initialize the
 field. Contains a mutex and must not be copied.
+
+If `.Init` panics, nothing is remembered, and the next call retries. To remember
+failures for some time, use `Mem` with `DurErr`, for example
+`Mem[DurErr[DurForever, DurSecond], Val, Ptr]`, which is otherwise equivalent.
 */
 type LazyIniter[Val any, Ptr IniterPtr[Val]] struct {
 	val  Opt[Val]
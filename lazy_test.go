@@ -2,7 +2,9 @@ package gg_test
 
 import (
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/mitranim/gg"
 	"github.com/mitranim/gg/gtest"
@@ -105,3 +107,124 @@ func BenchmarkLazy(b *testing.B) {
 		gg.Nop1(once.Get())
 	}
 }
+
+func TestOnceErr(t *testing.T) {
+	defer gtest.Catch(t)
+
+	t.Run(`success`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var count int
+		once := gg.OnceErr(time.Hour, func() (int, error) {
+			count++
+			return count * 10, nil
+		})
+
+		val, err := once()
+		gtest.NoErr(err)
+		gtest.Eq(val, 10)
+
+		val, err = once()
+		gtest.NoErr(err)
+		gtest.Eq(val, 10)
+		gtest.Eq(count, 1)
+
+		val, err = gg.OnceErr[int](time.Hour, nil)()
+		gtest.NoErr(err)
+		gtest.Zero(val)
+	})
+
+	t.Run(`failure`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var clock gg.ClockFake
+		var count int
+		once := gg.OnceErrClock(&clock, time.Minute, func() (int, error) {
+			count++
+			switch count {
+			case 1:
+				return 0, testErrUntracedA
+			case 2:
+				panic(testErrUntracedB)
+			default:
+				return count * 10, nil
+			}
+		})
+
+		for range gg.Iter(3) {
+			val, err := once()
+			testWrappedErr(err, testErrUntracedA)
+			gtest.Zero(val)
+		}
+		gtest.Eq(count, 1, `must remember failures`)
+
+		clock.Advance(time.Minute)
+		_, err := once()
+		testWrappedErr(err, testErrUntracedA)
+		gtest.Eq(count, 1, `must remember failures until the duration has passed`)
+
+		clock.Advance(time.Second)
+		_, err = once()
+		testWrappedErr(err, testErrUntracedB)
+		gtest.Eq(count, 2)
+
+		clock.Advance(time.Minute + time.Second)
+		val, err := once()
+		gtest.NoErr(err)
+		gtest.Eq(val, 30)
+	})
+
+	t.Run(`waiters`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var clock gg.ClockFake
+		var count atomic.Int64
+		var gate sync.Mutex
+		arrived := make(chan struct{}, 1)
+
+		once := gg.OnceErrClock(&clock, time.Minute, func() (int, error) {
+			arrived <- struct{}{}
+			defer gg.Lock(&gate).Unlock()
+			count.Add(1)
+			return 0, testErrUntracedA
+		})
+
+		gate.Lock()
+		errs := make(chan error, 4)
+
+		for range gg.Iter(4) {
+			go func() {
+				_, err := once()
+				errs <- err
+			}()
+		}
+
+		/**
+		The other callers either wait for the lock held by the blocked call, or
+		call after it has failed. Either way, they must share the failure.
+		*/
+		<-arrived
+		gate.Unlock()
+
+		for range gg.Iter(4) {
+			testWrappedErr(<-errs, testErrUntracedA)
+		}
+		gtest.Eq(count.Load(), 1, `callers must share the failure`)
+	})
+
+	t.Run(`zero_duration`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var count int
+		once := gg.OnceErr(0, func() (int, error) {
+			count++
+			return 0, testErrUntracedA
+		})
+
+		for ind := range gg.Iter(3) {
+			_, err := once()
+			testWrappedErr(err, testErrUntracedA)
+			gtest.Eq(count, ind+1, `zero duration must not remember failures`)
+		}
+	})
+}
@@ -66,6 +66,7 @@ func (self LogTime) LogEnd() LogTime {
 	err := AnyErrTracedAt(recover(), 1)
 
 	if err != nil {
+		recObserve(err, true)
 		fmt.Fprintf(os.Stderr, "[%v] failed in %v\n", self.Msg, since)
 		panic(err)
 	}
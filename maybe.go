@@ -65,3 +65,97 @@ func (self *Maybe[_]) UnmarshalJSON(src []byte) error {
 	self.Err = nil
 	return json.Unmarshal(src, &self.Val)
 }
+
+/*
+Shortcut for creating a `Maybe` from the results of a function that returns
+`(A, error)`. If the error is non-nil, the value is discarded. Inverse of
+`Maybe.Pair`. Does not modify the error.
+*/
+func MaybeOf[A any](val A, err error) Maybe[A] {
+	if err != nil {
+		return MaybeErr[A](err)
+	}
+	return MaybeVal(val)
+}
+
+/*
+Returns the underlying value and error, suitable for returning from functions
+with the conventional `(A, error)` signature. Inverse of `MaybeOf`.
+*/
+func (self Maybe[A]) Pair() (A, error) { return self.Val, self.Err }
+
+/*
+Variant of `Catch1` that returns `Maybe`. Runs the given function, converting
+a panic to an error with a stack trace.
+*/
+func MaybeCatch1[A any](fun func() A) Maybe[A] { return MaybeOf(Catch1(fun)) }
+
+/*
+Variant of `Catch11` that returns `Maybe`. Runs the given function with the
+given input, converting a panic to an error with a stack trace.
+*/
+func MaybeCatch11[A, B any](fun func(A) B, val A) Maybe[B] {
+	return MaybeOf(Catch11(fun, val))
+}
+
+/*
+If the input has an error, returns that error. Otherwise calls the function
+with the input's value and returns the result. Panics in the function are
+converted to errors like in `Catch11`. Also see `MaybeAndThen`.
+*/
+func MaybeMap[A, B any](src Maybe[A], fun func(A) B) Maybe[B] {
+	if src.Err != nil {
+		return MaybeErr[B](src.Err)
+	}
+	return MaybeCatch11(fun, src.Val)
+}
+
+/*
+If the input has an error, returns that error. Otherwise calls the function
+with the input's value and returns its `Maybe`. Panics in the function are
+converted to errors like in `Catch11`. Also see `MaybeMap`.
+*/
+func MaybeAndThen[A, B any](src Maybe[A], fun func(A) Maybe[B]) Maybe[B] {
+	if src.Err != nil {
+		return MaybeErr[B](src.Err)
+	}
+
+	out, err := Catch11(fun, src.Val)
+	if err != nil {
+		return MaybeErr[B](err)
+	}
+	return out
+}
+
+/*
+If the input has no error, returns it as-is. Otherwise calls the function with
+the input's error, allowing to recover from it or to replace it. Panics in the
+function are converted to errors like in `Catch11`.
+*/
+func MaybeOrElse[A any](src Maybe[A], fun func(error) Maybe[A]) Maybe[A] {
+	if src.Err == nil || fun == nil {
+		return src
+	}
+
+	out, err := Catch11(fun, src.Err)
+	if err != nil {
+		return MaybeErr[A](err)
+	}
+	return out
+}
+
+/*
+Combines multiple `Maybe` into one. If none of the inputs have errors, the
+output contains all values in the original order. Otherwise the output
+contains all errors, combined via `Errs.Err`.
+*/
+func MaybeCollect[A any](src []Maybe[A]) Maybe[[]A] {
+	var errs Errs
+	for _, val := range src {
+		errs.Add(val.Err)
+	}
+	if len(errs) > 0 {
+		return MaybeErr[[]A](errs.Err())
+	}
+	return MaybeVal(Map(src, Maybe[A].Get))
+}
@@ -0,0 +1,119 @@
+package gg_test
+
+import (
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+func TestMaybeOf(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Eq(gg.MaybeOf(10, nil), gg.MaybeVal(10))
+	gtest.Eq(gg.MaybeOf(10, io.EOF), gg.MaybeErr[int](io.EOF))
+	gtest.Eq(gg.MaybeOf(strconv.Atoi(`10`)), gg.MaybeVal(10))
+
+	val, err := gg.MaybeVal(10).Pair()
+	gtest.Eq(val, 10)
+	gtest.NoErr(err)
+
+	val, err = gg.MaybeErr[int](io.EOF).Pair()
+	gtest.Zero(val)
+	gtest.Equal(err, io.EOF)
+}
+
+func TestMaybeCatch1(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Eq(gg.MaybeCatch1(func() int { return 10 }), gg.MaybeVal(10))
+	gtest.Zero(gg.MaybeCatch1[int](nil))
+
+	out := gg.MaybeCatch1(func() int { panic(io.EOF) })
+	gtest.Zero(out.Val)
+	gtest.ErrIs(out.Err, io.EOF)
+	gtest.True(gg.IsErrTraced(out.Err))
+}
+
+func TestMaybeCatch11(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Eq(gg.MaybeCatch11(strconv.Itoa, 10), gg.MaybeVal(`10`))
+
+	out := gg.MaybeCatch11(parseIntOk, `ten`)
+	gtest.Zero(out.Val)
+	gtest.ErrStr(`invalid syntax`, out.Err)
+	gtest.True(gg.IsErrTraced(out.Err))
+}
+
+func TestMaybeMap(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Eq(gg.MaybeMap(gg.MaybeVal(10), strconv.Itoa), gg.MaybeVal(`10`))
+	gtest.Eq(gg.MaybeMap(gg.MaybeErr[int](io.EOF), strconv.Itoa), gg.MaybeErr[string](io.EOF))
+
+	out := gg.MaybeMap(gg.MaybeVal(`ten`), parseIntOk)
+	gtest.ErrStr(`invalid syntax`, out.Err)
+}
+
+func TestMaybeAndThen(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Eq(gg.MaybeAndThen(gg.MaybeVal(`10`), parseIntMaybe), gg.MaybeVal(10))
+	gtest.ErrStr(`invalid syntax`, gg.MaybeAndThen(gg.MaybeVal(`ten`), parseIntMaybe).Err)
+	gtest.Eq(gg.MaybeAndThen(gg.MaybeErr[string](io.EOF), parseIntMaybe), gg.MaybeErr[int](io.EOF))
+
+	out := gg.MaybeAndThen(gg.MaybeVal(10), func(int) gg.Maybe[int] { panic(io.EOF) })
+	gtest.ErrIs(out.Err, io.EOF)
+	gtest.True(gg.IsErrTraced(out.Err))
+}
+
+func TestMaybeOrElse(t *testing.T) {
+	defer gtest.Catch(t)
+
+	fallback := func(err error) gg.Maybe[int] {
+		if err == io.EOF {
+			return gg.MaybeVal(-1)
+		}
+		return gg.MaybeErr[int](gg.Wrap(err, `unexpected`))
+	}
+
+	gtest.Eq(gg.MaybeOrElse(gg.MaybeVal(10), fallback), gg.MaybeVal(10))
+	gtest.Eq(gg.MaybeOrElse(gg.MaybeErr[int](io.EOF), fallback), gg.MaybeVal(-1))
+	gtest.Eq(gg.MaybeOrElse(gg.MaybeErr[int](io.EOF), nil), gg.MaybeErr[int](io.EOF))
+
+	out := gg.MaybeOrElse(gg.MaybeErr[int](io.ErrUnexpectedEOF), fallback)
+	gtest.Eq(out.Err.Error(), `unexpected: unexpected EOF`)
+
+	out = gg.MaybeOrElse(gg.MaybeErr[int](io.EOF), func(error) gg.Maybe[int] { panic(`fail`) })
+	gtest.ErrStr(`fail`, out.Err)
+}
+
+func TestMaybeCollect(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Zero(gg.MaybeCollect[int](nil))
+	gtest.Equal(
+		gg.MaybeCollect([]gg.Maybe[int]{gg.MaybeVal(10), gg.MaybeVal(20)}),
+		gg.MaybeVal([]int{10, 20}),
+	)
+
+	gtest.Equal(
+		gg.MaybeCollect([]gg.Maybe[int]{gg.MaybeVal(10), gg.MaybeErr[int](io.EOF)}),
+		gg.MaybeErr[[]int](io.EOF),
+	)
+
+	out := gg.MaybeCollect([]gg.Maybe[int]{
+		gg.MaybeErr[int](io.EOF),
+		gg.MaybeVal(10),
+		gg.MaybeErr[int](io.ErrUnexpectedEOF),
+	})
+	gtest.Zero(out.Val)
+	gtest.Equal(out.Err, error(gg.Errs{io.EOF, io.ErrUnexpectedEOF}))
+}
+
+func parseIntOk(src string) int { return gg.Try1(strconv.Atoi(src)) }
+
+func parseIntMaybe(src string) gg.Maybe[int] { return gg.MaybeOf(strconv.Atoi(src)) }
@@ -2,6 +2,7 @@ package gg
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -9,11 +10,12 @@ import (
 Tool for deduplicating and caching expensive work. All methods are safe for
 concurrent use. The first type parameter is used to determine expiration
 duration, and should be a zero-sized stateless type, such as `DurSecond`,
-`DurMinute`, `DurHour`, and `DurForever` provided by this package. The given
-type `Tar` must implement `Initer` on its pointer type: `(*Tar).Init`. The init
-method is used to populate data whenever it's missing or expired. See methods
-`Mem.Get` and `Mem.Peek`. A zero value of `Mem` is ready for use. Contains a
-synchronization primitive and must not be copied.
+`DurMinute`, `DurHour`, and `DurForever` provided by this package. For
+durations determined at runtime, use `MemDyn` instead. The given type `Tar` must
+implement `Initer` on its pointer type: `(*Tar).Init`. The init method is used
+to populate data whenever it's missing or expired. See methods `Mem.Get` and
+`Mem.Peek`. A zero value of `Mem` is ready for use. Contains a synchronization
+primitive and must not be copied.
 
 Usage example:
 
@@ -34,10 +36,15 @@ Usage example:
 	func init() { fmt.Println(dat.Models.Get()) }
 */
 type Mem[Dur Durationer, Tar any, Ptr IniterPtr[Tar]] struct {
-	val  Tar
-	ok   bool
-	inst time.Time
-	lock sync.RWMutex
+	val     Tar
+	ok      bool
+	inst    time.Time
+	lock    sync.RWMutex
+	busy    bool
+	gen     uint64
+	err     error
+	errInst time.Time
+	fails   atomic.Uint64
 }
 
 /*
@@ -61,22 +68,190 @@ case, 0 duration is considered indefinite, making the `Mem` never expire, and
 thus functionally equivalent to `LazyIniter`. Negative durations cause the `Mem`
 to expire immediately, making it pointless.
 
+If the `Durationer` type also implements `HardDurationer`, for example
+`DurStale`, this uses stale-while-revalidate. Expired data is returned as-is,
+without blocking, while one background goroutine refreshes it by calling
+`(*Tar).Init`. Only when the data is missing, or older than the hard duration,
+does this method block and initialize the data like in the default mode. As a
+special case, 0 hard duration allows to serve stale data indefinitely. If a
+background refresh panics, the previous data is kept, and the error is reported
+to observers registered via `RecObserve`, and is available via `Mem.Err` until
+the next successful refresh. After a failure, the next refresh is delayed by a
+second. A refresh which completes after the data was replaced or cleared by
+other methods, such as `Mem.Clear`, is discarded.
+
+By default, if `(*Tar).Init` panics, the panic propagates to the caller, and
+nothing is remembered: callers which were waiting retry the initialization one
+by one. If the `Durationer` type also implements `ErrDurationer`, for example
+`DurErr`, failures are recorded instead. Callers which were waiting for the
+failed call, and callers within the error duration after it, panic with the
+same error, each with its own stack trace added, without retrying. The first
+call after the error duration retries automatically. In stale-while-revalidate
+mode, a non-zero error duration replaces the default delay of the next
+background refresh after a failed one. The recorded error is available via
+`Mem.Err`.
+
 Compare `Mem.Peek` which does not perform initialization.
 */
-func (self *Mem[Dur, Tar, Ptr]) Get() Tar {
+func (self *Mem[Dur, Tar, Ptr]) Get() Tar { return self.get(memDurOf(Zero[Dur]())) }
+
+func (self *Mem[_, Tar, Ptr]) get(dur memDur) Tar {
+	/**
+	Observed before waiting for the lock. If it has changed by the time we
+	acquire the lock, we were waiting for a failed call, and share its error.
+	*/
+	var fails uint64
+	if dur.errs {
+		fails = self.fails.Load()
+	}
+
 	defer Lock(&self.lock).Unlock()
 
 	val, ok, inst := self.val, self.ok, self.inst
-	if ok && !isExpired(inst, Zero[Dur]().Duration()) {
+	if ok && !isExpired(inst, dur.dur) {
+		return val
+	}
+
+	if ok && dur.stale && !isExpired(inst, dur.hard) {
+		if self.refreshable(dur) {
+			self.busy = true
+			go self.refresh(self.gen)
+		}
 		return val
 	}
 
+	if dur.errs {
+		return self.initErr(dur, fails)
+	}
+
 	var tar Tar
 	Ptr(&tar).Init()
 	self.set(tar)
 	return tar
 }
 
+/*
+Must be called under the lock. Either panics with the recorded error, if it's
+still relevant for the caller which observed the given count of failures before
+waiting, or initializes the data, recording a failure.
+*/
+func (self *Mem[_, Tar, Ptr]) initErr(dur memDur, fails uint64) Tar {
+	if self.err != nil && (self.fails.Load() != fails || self.errCached(dur)) {
+		panic(WrapTracedAt(self.err, 1))
+	}
+
+	tar, err := Catch1(memInit[Tar, Ptr])
+	if err != nil {
+		self.fail(err)
+		panic(WrapTracedAt(err, 1))
+	}
+
+	self.set(tar)
+	return tar
+}
+
+/*
+Must be called under the lock. True if a background refresh may start: none is
+in progress, and the last failure, if any, is older than the error duration or,
+without one, `memRefreshBackoff`.
+*/
+func (self *Mem[_, _, _]) refreshable(dur memDur) bool {
+	if self.busy {
+		return false
+	}
+	if self.err == nil {
+		return true
+	}
+	if dur.err > 0 {
+		return !self.errCached(dur)
+	}
+	return isExpired(self.errInst, memRefreshBackoff)
+}
+
+/*
+Runs on a background goroutine started by `Mem.Get`. Performs initialization
+without holding the lock, so that readers keep receiving the stale data. The
+given generation is that of the data being refreshed; if the data has been
+replaced or cleared in the meantime, the result is discarded.
+*/
+func (self *Mem[_, Tar, Ptr]) refresh(gen uint64) {
+	tar, err := Catch1(memInit[Tar, Ptr])
+
+	defer Lock(&self.lock).Unlock()
+	self.busy = false
+
+	if self.gen != gen {
+		return
+	}
+	if err != nil {
+		self.fail(err)
+		return
+	}
+	self.set(tar)
+}
+
+func (self *Mem[_, _, _]) fail(err error) {
+	self.err = err
+	self.errInst = time.Now()
+	self.fails.Add(1)
+}
+
+// True if the recorded error is still within the error duration.
+func (self *Mem[_, _, _]) errCached(dur memDur) bool {
+	return self.err != nil && dur.err > 0 && !isExpired(self.errInst, dur.err)
+}
+
+/*
+Durations of `Mem`, obtained from its `Durationer` type, which may optionally
+implement `HardDurationer` and `ErrDurationer`.
+*/
+type memDur struct {
+	dur   time.Duration
+	hard  time.Duration
+	err   time.Duration
+	stale bool
+	errs  bool
+}
+
+func memDurOf(src Durationer) (out memDur) {
+	out.dur = src.Duration()
+
+	hard, ok := src.(HardDurationer)
+	if ok {
+		out.stale = true
+		out.hard = hard.HardDuration()
+
+		// Can't be shorter than the regular duration, unless indefinite.
+		if out.hard != 0 {
+			out.hard = MaxPrim2(out.hard, out.dur)
+		}
+	}
+
+	err, ok := src.(ErrDurationer)
+	if ok {
+		out.errs = true
+		out.err = err.ErrDuration()
+	}
+	return
+}
+
+func memInit[Tar any, Ptr IniterPtr[Tar]]() (out Tar) {
+	Ptr(&out).Init()
+	return
+}
+
+/*
+Returns the error of the last failed initialization, if any. Errors are
+recorded only by background refreshes in stale-while-revalidate mode, and when
+the `Durationer` type implements `ErrDurationer`, see `Mem.Get`. Cleared by a
+successful initialization, and by other methods which replace or clear the
+inner value.
+*/
+func (self *Mem[_, _, _]) Err() error {
+	defer Lock(self.lock.RLocker()).Unlock()
+	return self.err
+}
+
 /*
 Similar to `Mem.Get` but returns the inner value as-is, without checking
 expiration. If the value was never initialized, it's zero.
@@ -140,14 +315,27 @@ func (self *Mem[_, Tar, _]) clear() {
 	self.val = val
 	self.ok = false
 	self.inst = time.Time{}
+	self.gen++
+	self.err = nil
+	self.errInst = time.Time{}
 }
 
 func (self *Mem[_, Tar, _]) set(val Tar) {
 	self.val = val
 	self.ok = true
 	self.inst = time.Now()
+	self.gen++
+	self.err = nil
+	self.errInst = time.Time{}
 }
 
+/*
+Minimum delay between a failed background refresh in `Mem` and the next one,
+when the `Durationer` type doesn't provide a non-zero error duration. Prevents
+every call after a failure from immediately starting another refresh.
+*/
+const memRefreshBackoff = time.Second
+
 func isExpired(inst time.Time, dur time.Duration) bool {
 	return dur != 0 && inst.Add(dur).Before(time.Now())
 }
@@ -193,3 +381,49 @@ type DurForever struct{}
 
 // Implement `Durationer` by returning 0.
 func (DurForever) Duration() time.Duration { return 0 }
+
+/*
+Implements `HardDurationer` by combining two `Durationer` types, enabling
+stale-while-revalidate in `Mem`. The first type determines when data becomes
+stale, and the second type determines the hard expiration. Like other
+`Durationer` types in this package, this is zero-sized. Example:
+
+	// Refreshed in the background every minute, without blocking readers,
+	// unless the data is older than an hour.
+	gg.Mem[gg.DurStale[gg.DurMinute, gg.DurHour], Tar, *Tar]
+
+	// Refreshed in the background every hour, always serving stale data
+	// after the first initialization.
+	gg.Mem[gg.DurStale[gg.DurHour, gg.DurForever], Tar, *Tar]
+*/
+type DurStale[Fresh, Hard Durationer] struct{}
+
+// Implement `Durationer` by returning the duration of the first type.
+func (DurStale[Fresh, _]) Duration() time.Duration {
+	return Zero[Fresh]().Duration()
+}
+
+// Implement `HardDurationer` by returning the duration of the second type.
+func (DurStale[_, Hard]) HardDuration() time.Duration {
+	return Zero[Hard]().Duration()
+}
+
+/*
+Implements `ErrDurationer` by combining two `Durationer` types, enabling
+recording of failures in `Mem`. The first type determines the regular
+expiration, and the second type determines how long failures are remembered.
+Like other `Durationer` types in this package, this is zero-sized. To combine
+this with stale-while-revalidate, define a custom type which implements both
+`HardDurationer` and `ErrDurationer`. Example:
+
+	// Initialized once, like `LazyIniter`. If initialization fails, callers
+	// receive the same error for a second, then it's retried.
+	gg.Mem[gg.DurErr[gg.DurForever, gg.DurSecond], Tar, *Tar]
+*/
+type DurErr[Dur, Err Durationer] struct{}
+
+// Implement `Durationer` by returning the duration of the first type.
+func (DurErr[Dur, _]) Duration() time.Duration { return Zero[Dur]().Duration() }
+
+// Implement `ErrDurationer` by returning the duration of the second type.
+func (DurErr[_, Err]) ErrDuration() time.Duration { return Zero[Err]().Duration() }
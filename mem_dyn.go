@@ -0,0 +1,127 @@
+package gg
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+Shortcut for creating `MemDyn` with the given duration. The following is
+equivalent:
+
+	NewMemDyn[Tar](dur)
+
+	mem := new(MemDyn[Tar, *Tar])
+	mem.SetDuration(dur)
+*/
+func NewMemDyn[Tar any, Ptr IniterPtr[Tar]](dur time.Duration) *MemDyn[Tar, Ptr] {
+	out := new(MemDyn[Tar, Ptr])
+	out.SetDuration(dur)
+	return out
+}
+
+/*
+Variant of `Mem` where the expiration duration is provided at runtime, rather
+than by a `Durationer` type, and may be changed at any time, for example when
+the configuration is reloaded. Changes apply to the current data: expiration is
+always checked against the latest duration. Otherwise, behaves like `Mem` in the
+default mode. The duration has the same semantics: 0 is indefinite, which is
+also the default in a zero value.
+
+Supports jitter, see `MemDyn.SetJitter`, to avoid refreshing the data at the
+same time in many instances of one program, or in many `MemDyn` created at the
+same time.
+
+All methods are safe for concurrent use. A zero value is ready for use.
+Contains a synchronization primitive and must not be copied.
+*/
+type MemDyn[Tar any, Ptr IniterPtr[Tar]] struct {
+	mem  Mem[DurForever, Tar, Ptr]
+	dur  atomic.Int64
+	frac atomic.Uint64
+	once sync.Once
+	rnd  float64
+}
+
+/*
+Same as `Mem.Get`, using the current duration, adjusted for jitter. See
+`MemDyn.SetDuration` and `MemDyn.SetJitter`.
+*/
+func (self *MemDyn[Tar, _]) Get() Tar {
+	return self.mem.get(memDur{dur: self.duration()})
+}
+
+// Same as `Mem.Peek`.
+func (self *MemDyn[Tar, _]) Peek() Tar { return self.mem.Peek() }
+
+// Same as `Mem.Clear`.
+func (self *MemDyn[_, _]) Clear() { self.mem.Clear() }
+
+// Implement `json.Marshaler`. Same as `Mem.MarshalJSON`.
+func (self *MemDyn[_, _]) MarshalJSON() ([]byte, error) {
+	if self == nil {
+		return ToBytes(`null`), nil
+	}
+	return self.mem.MarshalJSON()
+}
+
+// Implement `json.Unmarshaler`. Same as `Mem.UnmarshalJSON`.
+func (self *MemDyn[_, _]) UnmarshalJSON(src []byte) error {
+	return self.mem.UnmarshalJSON(src)
+}
+
+// Returns the expiration duration, without jitter.
+func (self *MemDyn[_, _]) Duration() time.Duration {
+	return time.Duration(self.dur.Load())
+}
+
+/*
+Sets the expiration duration. Affects the current data, which may become
+expired as a result, causing the next call to `MemDyn.Get` to reinitialize it.
+*/
+func (self *MemDyn[_, _]) SetDuration(val time.Duration) {
+	self.dur.Store(int64(val))
+}
+
+// Returns the jitter fraction, see `MemDyn.SetJitter`.
+func (self *MemDyn[_, _]) Jitter() float64 {
+	return math.Float64frombits(self.frac.Load())
+}
+
+/*
+Sets the jitter fraction, clamped to the range `[0,1]`. Like in `BackoffJitter`,
+the duration is reduced by a random amount up to this fraction. For example,
+with the duration of an hour and jitter of 0.1, the data expires after a period
+between 54 and 60 minutes. The random factor is chosen once per instance, so
+each instance expires at regular intervals, but different instances, for
+example in different processes, expire at different times. Doesn't apply to
+the indefinite duration.
+*/
+func (self *MemDyn[_, _]) SetJitter(val float64) {
+	if !(val > 0) {
+		val = 0
+	} else if val > 1 {
+		val = 1
+	}
+	self.frac.Store(math.Float64bits(val))
+}
+
+/*
+Returns the duration after applying the jitter. The result is never 0, because
+it would make the data never expire.
+*/
+func (self *MemDyn[_, _]) duration() time.Duration {
+	dur := self.Duration()
+	frac := self.Jitter()
+	if dur <= 0 || frac <= 0 {
+		return dur
+	}
+
+	self.once.Do(self.initRnd)
+	return MaxPrim2(dur-time.Duration(float64(dur)*frac*self.rnd), 1)
+}
+
+func (self *MemDyn[_, _]) initRnd() { self.rnd = rand.Float64() }
@@ -0,0 +1,79 @@
+package gg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+func TestMemDyn(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var mem gg.MemDyn[MemStaleVal, *MemStaleVal]
+	gtest.Zero(mem.Duration())
+	gtest.Eq(gg.JsonString(&mem), `null`)
+
+	num := mem.Get().Num
+	gg.MemDynAge(&mem, time.Hour*1000)
+	gtest.Eq(mem.Get().Num, num, `zero duration must be indefinite`)
+
+	mem.SetDuration(time.Hour)
+	gtest.Eq(mem.Duration(), time.Hour)
+	gtest.NotEq(mem.Get().Num, num, `changing the duration must apply to the current data`)
+
+	num = mem.Get().Num
+	gtest.Eq(mem.Peek().Num, num)
+	gtest.Eq(gg.JsonString(&mem), `{"Num":`+gg.String(num)+`}`)
+
+	gg.MemDynAge(&mem, time.Minute*59)
+	gtest.Eq(mem.Get().Num, num)
+
+	mem.SetDuration(time.Minute * 30)
+	gtest.NotEq(mem.Get().Num, num)
+
+	mem.Clear()
+	gtest.Zero(mem.Peek())
+
+	gtest.NoErr(mem.UnmarshalJSON([]byte(`{"Num":-1}`)))
+	gtest.Eq(mem.Get().Num, -1)
+
+	gtest.Eq(gg.JsonString((*gg.MemDyn[MemStaleVal, *MemStaleVal])(nil)), `null`)
+}
+
+func TestMemDyn_SetJitter(t *testing.T) {
+	defer gtest.Catch(t)
+
+	mem := gg.NewMemDyn[MemStaleVal](time.Hour)
+	gtest.Zero(mem.Jitter())
+	gtest.Eq(gg.MemDynDuration(mem), time.Hour)
+
+	mem.SetJitter(0.1)
+	gtest.Eq(mem.Jitter(), 0.1)
+
+	dur := gg.MemDynDuration(mem)
+	gtest.LessEqPrim(time.Minute*54, dur)
+	gtest.LessEqPrim(dur, time.Hour)
+	gtest.Eq(gg.MemDynDuration(mem), dur, `jitter must be stable per instance`)
+
+	gg.MemDynSetRnd(mem, 0.5)
+	gtest.Eq(gg.MemDynDuration(mem), time.Minute*57)
+
+	mem.SetJitter(2)
+	gtest.Eq(mem.Jitter(), 1)
+	gtest.Eq(gg.MemDynDuration(mem), time.Minute*30)
+
+	mem.SetJitter(-1)
+	gtest.Zero(mem.Jitter())
+	gtest.Eq(gg.MemDynDuration(mem), time.Hour)
+
+	mem.SetJitter(1)
+	mem.SetDuration(0)
+	gtest.Zero(gg.MemDynDuration(mem), `jitter must not apply to the indefinite duration`)
+
+	num := mem.Get().Num
+	mem.SetDuration(time.Hour)
+	gg.MemDynAge(mem, time.Minute*31)
+	gtest.NotEq(mem.Get().Num, num, `must expire according to the jittered duration`)
+}
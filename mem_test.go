@@ -1,7 +1,10 @@
 package gg_test
 
 import (
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/mitranim/gg"
 	"github.com/mitranim/gg/gtest"
@@ -156,3 +159,225 @@ func testPeekerNotZero[Tar any, Src gg.Peeker[Tar]](src Src) {
 	gtest.NotZero(src.Peek())
 	gtest.NotZero(src.Peek())
 }
+
+var (
+	memStaleNum  atomic.Int64
+	memStaleInit atomic.Int64
+	memStaleFail atomic.Bool
+	memStaleGate sync.Mutex
+)
+
+type MemStaleVal struct{ Num int }
+
+func (self *MemStaleVal) Init() {
+	memStaleInit.Add(1)
+	defer gg.Lock(&memStaleGate).Unlock()
+	if memStaleFail.Load() {
+		panic(testErrUntracedA)
+	}
+	self.Num = int(memStaleNum.Add(1))
+}
+
+func testMemRefreshed[Dur gg.Durationer, Tar any, Ptr gg.IniterPtr[Tar]](mem *gg.Mem[Dur, Tar, Ptr]) {
+	for gg.MemBusy(mem) {
+		time.Sleep(time.Microsecond * 10)
+	}
+}
+
+func TestMem_Get_stale(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var mem gg.Mem[gg.DurStale[gg.DurMinute, gg.DurHour], MemStaleVal, *MemStaleVal]
+	base := int(memStaleNum.Load())
+
+	gtest.Eq(mem.Get().Num, base+1)
+	gtest.False(gg.MemBusy(&mem))
+
+	t.Run(`refresh`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		gg.MemAge(&mem, time.Minute*2)
+		memStaleGate.Lock()
+
+		gtest.Eq(mem.Get().Num, base+1, `must serve stale data without blocking`)
+		gtest.Eq(mem.Get().Num, base+1)
+		gtest.True(gg.MemBusy(&mem))
+		gtest.Eq(gg.JsonString(&mem), `{"Num":`+gg.String(base+1)+`}`)
+
+		memStaleGate.Unlock()
+		testMemRefreshed(&mem)
+
+		gtest.Eq(mem.Get().Num, base+2)
+		gtest.Eq(int(memStaleNum.Load()), base+2, `must refresh only once`)
+		gtest.NoErr(mem.Err())
+	})
+
+	t.Run(`failure`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var events recEvents
+		defer gg.RecObserve(events.Add)()
+
+		memStaleFail.Store(true)
+		defer memStaleFail.Store(false)
+
+		gg.MemAge(&mem, time.Minute*2)
+		gtest.Eq(mem.Get().Num, base+2)
+		testMemRefreshed(&mem)
+
+		testWrappedErr(mem.Err(), testErrUntracedA)
+		gtest.Eq(mem.Peek().Num, base+2, `must keep the previous value`)
+		gtest.Len(events.Take(), 1)
+
+		memStaleFail.Store(false)
+		gtest.Eq(mem.Get().Num, base+2)
+		gtest.False(gg.MemBusy(&mem), `must not refresh immediately after a failure`)
+
+		gg.MemAge(&mem, time.Second*2)
+		gtest.Eq(mem.Get().Num, base+2)
+		testMemRefreshed(&mem)
+
+		gtest.NoErr(mem.Err())
+		gtest.Eq(mem.Get().Num, base+3)
+	})
+
+	t.Run(`hard_expiry`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		gg.MemAge(&mem, time.Hour*2)
+		gtest.Eq(mem.Get().Num, base+4, `must block when the data is too old`)
+		gtest.False(gg.MemBusy(&mem))
+
+		gg.MemAge(&mem, time.Hour*2)
+		memStaleFail.Store(true)
+		defer memStaleFail.Store(false)
+
+		gtest.PanicErrIs(testErrUntracedA, func() { mem.Get() })
+	})
+
+	t.Run(`outdated`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var mem gg.Mem[gg.DurStale[gg.DurMinute, gg.DurHour], MemStaleVal, *MemStaleVal]
+		mem.Get()
+
+		gg.MemAge(&mem, time.Minute*2)
+		memStaleGate.Lock()
+		mem.Get()
+		gtest.True(gg.MemBusy(&mem))
+
+		mem.Clear()
+		memStaleGate.Unlock()
+		testMemRefreshed(&mem)
+		gtest.Zero(mem.Peek(), `must discard a refresh which started before clearing`)
+
+		mem.Get()
+		gg.MemAge(&mem, time.Minute*2)
+		memStaleGate.Lock()
+		mem.Get()
+
+		gtest.NoErr(mem.UnmarshalJSON([]byte(`{"Num":-1}`)))
+		memStaleGate.Unlock()
+		testMemRefreshed(&mem)
+		gtest.Eq(mem.Peek().Num, -1, `must discard a refresh which started before decoding`)
+	})
+}
+
+func TestMem_Get_stale_forever(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var mem gg.Mem[gg.DurStale[gg.DurMinute, gg.DurForever], MemStaleVal, *MemStaleVal]
+
+	num := mem.Get().Num
+	gg.MemAge(&mem, time.Hour*1000)
+
+	gtest.Eq(mem.Get().Num, num, `zero hard duration must allow stale data indefinitely`)
+	testMemRefreshed(&mem)
+	gtest.Eq(mem.Get().Num, num+1)
+
+	gtest.Eq(gg.DurStale[gg.DurMinute, gg.DurForever]{}.Duration(), time.Minute)
+	gtest.Zero(gg.DurStale[gg.DurMinute, gg.DurForever]{}.HardDuration())
+}
+
+type MemErrDur struct{}
+
+func (MemErrDur) Duration() time.Duration     { return time.Hour }
+func (MemErrDur) HardDuration() time.Duration { return 0 }
+func (MemErrDur) ErrDuration() time.Duration  { return time.Minute }
+
+func TestMem_Get_DurErr(t *testing.T) {
+	defer gtest.Catch(t)
+
+	memStaleFail.Store(true)
+	defer memStaleFail.Store(false)
+
+	var mem gg.Mem[gg.DurErr[gg.DurForever, gg.DurMinute], MemStaleVal, *MemStaleVal]
+	base := int(memStaleNum.Load())
+
+	t.Run(`waiters`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		init := memStaleInit.Load()
+		memStaleGate.Lock()
+		errs := make(chan error, 4)
+
+		for range gg.Iter(4) {
+			go func() { errs <- gg.Catch(func() { mem.Get() }) }()
+		}
+
+		/**
+		The other callers either wait for the lock held by the blocked
+		initialization, or call after it has failed. Either way, they must share
+		the failure, which is remembered for the error duration.
+		*/
+		for memStaleInit.Load() == init {
+			time.Sleep(time.Microsecond * 10)
+		}
+		memStaleGate.Unlock()
+
+		for range gg.Iter(4) {
+			testWrappedErr(<-errs, testErrUntracedA)
+		}
+		gtest.Eq(memStaleInit.Load(), init+1, `must initialize only once`)
+	})
+
+	t.Run(`cached`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		memStaleFail.Store(false)
+
+		err := gg.Catch(func() { mem.Get() })
+		testWrappedErr(err, testErrUntracedA)
+		testWrappedErr(mem.Err(), testErrUntracedA)
+		gtest.Eq(int(memStaleNum.Load()), base, `must not retry within the error duration`)
+
+		gg.MemAge(&mem, time.Minute+time.Second)
+		gtest.Eq(mem.Get().Num, base+1, `must retry after the error duration`)
+		gtest.NoErr(mem.Err())
+
+		gg.MemAge(&mem, time.Hour*1000)
+		gtest.Eq(mem.Get().Num, base+1)
+	})
+
+	t.Run(`stale`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var mem gg.Mem[MemErrDur, MemStaleVal, *MemStaleVal]
+		num := mem.Get().Num
+
+		memStaleFail.Store(true)
+		gg.MemAge(&mem, time.Hour*2)
+		gtest.Eq(mem.Get().Num, num)
+		testMemRefreshed(&mem)
+		testWrappedErr(mem.Err(), testErrUntracedA)
+
+		memStaleFail.Store(false)
+		gtest.Eq(mem.Get().Num, num)
+		gtest.False(gg.MemBusy(&mem), `must not refresh within the error duration`)
+
+		gg.MemAge(&mem, time.Minute+time.Second)
+		gtest.Eq(mem.Get().Num, num)
+		testMemRefreshed(&mem)
+		gtest.Eq(mem.Get().Num, num+1)
+	})
+}
@@ -0,0 +1,531 @@
+package gg
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+This file implements a small toolkit for pipelines of goroutines connected by
+channels ([Chan]). Stages are created via generic functions such as `PipeMap`,
+`PipeFilter`, `PipeBatch`, `PipeMerge`, `PipeFanOut`, and are coordinated by a
+shared `Pipe`, which owns the pipeline's context, goroutines, and errors.
+*/
+
+/*
+Coordinates a pipeline of stages connected by channels. Must be created via
+`NewPipe`. Features:
+
+  - Every stage runs on its own goroutines, tracked by the pipe.
+  - Every send and receive is aborted when the pipe's context is done, which
+    guarantees that all goroutines terminate, even when the consumer stops
+    reading early, as long as it calls `Pipe.Stop`.
+  - Panics in stage functions are converted to errors with stack traces. The
+    first error cancels the pipe's context, stopping all stages, and is
+    returned by `Pipe.Wait`.
+  - When the parent context is canceled, all stages stop, and `Pipe.Wait`
+    returns the context's error.
+
+Example:
+
+	pipe := gg.NewPipe(ctx)
+	defer pipe.Stop()
+
+	ids := gg.PipeFrom(pipe, someIds)
+	recs := gg.PipeMap(pipe, ids, gg.PipeConf{Par: 8, Ordered: true}, fetchRecord)
+	batches := gg.PipeBatch(pipe, recs, 100)
+	gg.Try(gg.PipeEach(pipe, batches, saveRecords))
+
+When reading the output channel directly, the consumer may stop at any point,
+and must then call `Pipe.Stop` and `Pipe.Wait`:
+
+	for val := range out {
+		if done(val) {
+			break
+		}
+	}
+	pipe.Stop()
+	gg.Try(pipe.Wait())
+
+Channels provided by external code as inputs must be closed by their producers,
+and those producers must stop sending when the pipe's context is done.
+*/
+type Pipe struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	gro    sync.WaitGroup
+	lock   sync.Mutex
+	err    error
+}
+
+/*
+Creates a `Pipe` whose context is derived from the given context. If the given
+context is nil, `context.Background` is used.
+*/
+func NewPipe(ctx context.Context) *Pipe {
+	out := new(Pipe)
+	out.parent = ctxOr(ctx)
+	out.ctx, out.cancel = context.WithCancel(out.parent)
+	return out
+}
+
+/*
+Returns the context passed to every stage function. It's canceled on the first
+error, when `Pipe.Stop` is called, or when the parent context is done.
+*/
+func (self *Pipe) Ctx() context.Context { return self.ctx }
+
+/*
+Stops all stages by canceling the pipe's context. Doesn't wait for them to
+terminate; use `Pipe.Wait` for that. Stopping is not considered an error.
+Idempotent.
+*/
+func (self *Pipe) Stop() { self.cancel() }
+
+/*
+Waits for all stages to terminate, and returns the first error, if any, with a
+stack trace connecting the trace of the failed goroutine with the trace of the
+caller. If the pipeline was interrupted by the parent context, the error is
+the context's error. Unless the consumer has read every output channel to the
+end, `Pipe.Stop` must be called first, otherwise this may block forever.
+*/
+func (self *Pipe) Wait() error { return self.waitAt(1) }
+
+func (self *Pipe) waitAt(skip int) error {
+	self.gro.Wait()
+	self.cancel()
+	return WrapTracedAt(self.getErr(), skip+1)
+}
+
+func (self *Pipe) getErr() error {
+	defer Lock(&self.lock).Unlock()
+	return self.err
+}
+
+func (self *Pipe) fail(err error) {
+	defer Lock(&self.lock).Unlock()
+	if self.err == nil {
+		self.err = err
+		self.cancel()
+	}
+}
+
+/*
+Called when a stage is interrupted by the pipe's context. Only cancelation of
+the parent context is considered an error.
+*/
+func (self *Pipe) interrupt() {
+	err := self.parent.Err()
+	if err != nil {
+		self.fail(err)
+	}
+}
+
+// Must be deferred.
+func (self *Pipe) rec() {
+	err := AnyErrTracedAt(recover(), 1)
+	if err != nil {
+		recObserve(err, false)
+		self.fail(err)
+	}
+}
+
+func (self *Pipe) spawn(fun func()) {
+	self.gro.Add(1)
+	go self.run(fun)
+}
+
+func (self *Pipe) run(fun func()) {
+	defer self.gro.Done()
+	defer self.rec()
+	fun()
+}
+
+/*
+Configures `PipeMap` and `PipeFilter`. The zero value runs the stage function
+on one goroutine, with an unbuffered output channel.
+*/
+type PipeConf struct {
+	// When above 1, the stage function runs on this many goroutines.
+	Par int
+
+	/**
+	If true, outputs are emitted in the order of inputs, even when `.Par` is
+	above 1. Otherwise outputs are emitted in the order of completion. With
+	one goroutine, the order is always preserved.
+	*/
+	Ordered bool
+
+	// Buffer size of the output channel.
+	Buf int
+}
+
+/*
+Creates a stage which sends the elements of the given slice, and closes the
+output channel when done.
+*/
+func PipeFrom[A any](pipe *Pipe, src []A) Chan[A] {
+	out := make(Chan[A])
+	pipe.spawn(func() {
+		defer out.Close()
+		for _, val := range src {
+			if !pipeSend(pipe, out, val) {
+				return
+			}
+		}
+	})
+	return out
+}
+
+/*
+Creates a stage which runs the given generator function on a new goroutine.
+The generator emits values by calling the provided `send` function, which
+returns false if the pipeline has been stopped, in which case the generator
+should return immediately. The output channel is closed when the generator
+returns. Panics in the generator are handled like in other stages.
+*/
+func PipeGen[A any](pipe *Pipe, fun func(ctx context.Context, send func(A) bool)) Chan[A] {
+	out := make(Chan[A])
+	pipe.spawn(func() {
+		defer out.Close()
+		if fun != nil {
+			fun(pipe.ctx, func(val A) bool { return pipeSend(pipe, out, val) })
+		}
+	})
+	return out
+}
+
+/*
+Creates a stage which calls the given function on each input value, and sends
+the results. Parallelism and ordering are configured via `PipeConf`. If the
+function panics, the pipeline is stopped with the resulting error.
+*/
+func PipeMap[A, B any](pipe *Pipe, src <-chan A, conf PipeConf, fun func(context.Context, A) B) Chan[B] {
+	return pipeStage(pipe, src, conf, func(ctx context.Context, val A) (B, bool) {
+		return fun(ctx, val), true
+	})
+}
+
+/*
+Creates a stage which sends only the input values for which the given function
+returns true. Parallelism and ordering are configured via `PipeConf`. If the
+function panics, the pipeline is stopped with the resulting error.
+*/
+func PipeFilter[A any](pipe *Pipe, src <-chan A, conf PipeConf, fun func(context.Context, A) bool) Chan[A] {
+	return pipeStage(pipe, src, conf, func(ctx context.Context, val A) (A, bool) {
+		return val, fun(ctx, val)
+	})
+}
+
+/*
+Creates a stage which groups input values into slices of the given size, at
+least 1. The last batch may be smaller. Each batch is a new slice.
+*/
+func PipeBatch[A any](pipe *Pipe, src <-chan A, size int) Chan[[]A] {
+	size = MaxPrim2(size, 1)
+	out := make(Chan[[]A])
+
+	pipe.spawn(func() {
+		defer out.Close()
+
+		var buf []A
+		for {
+			val, ok := pipeRecv(pipe, src)
+			if !ok {
+				break
+			}
+
+			buf = append(buf, val)
+			if len(buf) >= size {
+				if !pipeSend(pipe, out, buf) {
+					return
+				}
+				buf = nil
+			}
+		}
+
+		if len(buf) > 0 {
+			pipeSend(pipe, out, buf)
+		}
+	})
+	return out
+}
+
+/*
+Fan-in. Creates a stage which forwards values from all given channels into one
+channel, in the order of arrival. The output channel is closed after all inputs
+are closed.
+*/
+func PipeMerge[A any](pipe *Pipe, src ...<-chan A) Chan[A] {
+	out := make(Chan[A])
+	var gro sync.WaitGroup
+
+	for _, src := range src {
+		if src == nil {
+			continue
+		}
+		src := src
+		gro.Add(1)
+		pipe.spawn(func() {
+			defer gro.Done()
+			pipeForward(pipe, src, out)
+		})
+	}
+
+	pipe.spawn(func() {
+		defer out.Close()
+		gro.Wait()
+	})
+	return out
+}
+
+/*
+Fan-out. Creates the given amount of output channels, at least 1, and
+distributes the input values among them, so that each value is received by
+exactly one output. A value goes to whichever output is ready first; a slow
+consumer holds up at most one value. Each output is closed after the input is
+closed. Every output must be consumed, or the pipe must be stopped.
+*/
+func PipeFanOut[A any](pipe *Pipe, src <-chan A, count int) []Chan[A] {
+	out := make([]Chan[A], MaxPrim2(count, 1))
+	for ind := range out {
+		tar := make(Chan[A])
+		out[ind] = tar
+		pipe.spawn(func() {
+			defer tar.Close()
+			pipeForward(pipe, src, tar)
+		})
+	}
+	return out
+}
+
+/*
+Consumes the given channel on the current goroutine, until it's closed or the
+pipe is stopped, then waits for the pipe via `Pipe.Wait`. Returns the received
+values and the pipe's error.
+*/
+func PipeCollect[A any](pipe *Pipe, src <-chan A) ([]A, error) {
+	var out []A
+	for {
+		val, ok := pipeRecv(pipe, src)
+		if !ok {
+			break
+		}
+		out = append(out, val)
+	}
+	return out, pipe.waitAt(1)
+}
+
+/*
+Consumes the given channel on the current goroutine, calling the given function
+for each value, until the channel is closed or the pipe is stopped, then waits
+for the pipe via `Pipe.Wait`. If the function panics, the pipe is stopped, and
+the resulting error is returned.
+*/
+func PipeEach[A any](pipe *Pipe, src <-chan A, fun func(context.Context, A)) error {
+	var each func(context.Context, A) (struct{}, bool)
+	if fun != nil {
+		each = pipeEachFun(fun)
+	}
+
+	for {
+		val, ok := pipeRecv(pipe, src)
+		if !ok {
+			break
+		}
+		if pipeDone(pipe) {
+			break
+		}
+		if each != nil {
+			pipeCall(pipe, each, val)
+		}
+	}
+	return pipe.waitAt(1)
+}
+
+func pipeEachFun[A any](fun func(context.Context, A)) func(context.Context, A) (struct{}, bool) {
+	return func(ctx context.Context, val A) (_ struct{}, _ bool) {
+		fun(ctx, val)
+		return
+	}
+}
+
+/* Internal */
+
+/*
+Sends a value, unless the pipe's context is done first. Returns true if the
+value was sent.
+*/
+func pipeSend[A any](pipe *Pipe, out chan<- A, val A) bool {
+	select {
+	case out <- val:
+		return true
+	case <-pipe.ctx.Done():
+		pipe.interrupt()
+		return false
+	}
+}
+
+/*
+True if the pipe's context is done. A `select` between a ready channel and a
+done context picks either at random, so stages must check this before calling
+the stage function, to avoid calling it after the pipeline has been stopped.
+*/
+func pipeDone(pipe *Pipe) bool {
+	if pipe.ctx.Err() != nil {
+		pipe.interrupt()
+		return true
+	}
+	return false
+}
+
+/*
+Receives a value, unless the pipe's context is done first. Returns false if
+the channel is closed or the context is done.
+*/
+func pipeRecv[A any](pipe *Pipe, src <-chan A) (_ A, _ bool) {
+	select {
+	case val, ok := <-src:
+		return val, ok
+	case <-pipe.ctx.Done():
+		pipe.interrupt()
+		return
+	}
+}
+
+func pipeForward[A any](pipe *Pipe, src <-chan A, out chan<- A) {
+	for {
+		val, ok := pipeRecv(pipe, src)
+		if !ok || !pipeSend(pipe, out, val) {
+			return
+		}
+	}
+}
+
+/*
+Calls the stage function, converting a panic to an error which stops the pipe.
+On panic, the result is not emitted.
+*/
+func pipeCall[A, B any](pipe *Pipe, fun func(context.Context, A) (B, bool), val A) (_ B, _ bool) {
+	defer pipe.rec()
+	return fun(pipe.ctx, val)
+}
+
+func pipeStage[A, B any](pipe *Pipe, src <-chan A, conf PipeConf, fun func(context.Context, A) (B, bool)) Chan[B] {
+	out := make(Chan[B], MaxPrim2(conf.Buf, 0))
+	par := MaxPrim2(conf.Par, 1)
+
+	if par == 1 {
+		pipe.spawn(func() {
+			defer out.Close()
+			pipeWork(pipe, src, out, fun)
+		})
+		return out
+	}
+
+	if conf.Ordered {
+		pipeStageOrdered(pipe, src, out, par, fun)
+		return out
+	}
+
+	var gro sync.WaitGroup
+	gro.Add(par)
+	for range Iter(par) {
+		pipe.spawn(func() {
+			defer gro.Done()
+			pipeWork(pipe, src, out, fun)
+		})
+	}
+
+	pipe.spawn(func() {
+		defer out.Close()
+		gro.Wait()
+	})
+	return out
+}
+
+func pipeWork[A, B any](pipe *Pipe, src <-chan A, out chan<- B, fun func(context.Context, A) (B, bool)) {
+	for {
+		val, ok := pipeRecv(pipe, src)
+		if !ok || pipeDone(pipe) {
+			return
+		}
+
+		res, ok := pipeCall(pipe, fun, val)
+		if ok && !pipeSend(pipe, out, res) {
+			return
+		}
+	}
+}
+
+type pipeJob[A, B any] struct {
+	val A
+	res chan pipeRes[B]
+}
+
+type pipeRes[A any] struct {
+	val A
+	ok  bool
+}
+
+/*
+Preserves the order of outputs with parallel workers. The dispatcher sends each
+job to the workers, and its result channel to the emitter, in the order of
+inputs. The emitter waits for each result in that order. The buffers of the
+internal channels limit how far the workers may run ahead of the emitter.
+*/
+func pipeStageOrdered[A, B any](pipe *Pipe, src <-chan A, out Chan[B], par int, fun func(context.Context, A) (B, bool)) {
+	jobs := make(chan pipeJob[A, B], par)
+	order := make(chan chan pipeRes[B], par)
+
+	pipe.spawn(func() {
+		defer close(jobs)
+		defer close(order)
+
+		for {
+			val, ok := pipeRecv(pipe, src)
+			if !ok {
+				return
+			}
+
+			res := make(chan pipeRes[B], 1)
+			if !pipeSend(pipe, order, res) || !pipeSend(pipe, jobs, pipeJob[A, B]{val, res}) {
+				return
+			}
+		}
+	})
+
+	for range Iter(par) {
+		pipe.spawn(func() {
+			for {
+				job, ok := pipeRecv(pipe, jobs)
+				if !ok || pipeDone(pipe) {
+					return
+				}
+				val, ok := pipeCall(pipe, fun, job.val)
+				job.res <- pipeRes[B]{val, ok}
+			}
+		})
+	}
+
+	pipe.spawn(func() {
+		defer out.Close()
+
+		for {
+			res, ok := pipeRecv(pipe, order)
+			if !ok {
+				return
+			}
+
+			val, ok := pipeRecv(pipe, res)
+			if !ok {
+				return
+			}
+
+			if val.ok && !pipeSend(pipe, out, val.val) {
+				return
+			}
+		}
+	})
+}
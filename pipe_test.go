@@ -0,0 +1,282 @@
+package gg_test
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/grepr"
+	"github.com/mitranim/gg/gtest"
+)
+
+func TestPipeMap(t *testing.T) {
+	defer gtest.Catch(t)
+
+	src := gg.Span(100)
+	exp := gg.Map(src, gg.String[int])
+
+	test := func(conf gg.PipeConf, ordered bool) {
+		pipe := gg.NewPipe(nil)
+		defer pipe.Stop()
+
+		out := gg.PipeMap(pipe, gg.PipeFrom(pipe, src), conf, func(_ context.Context, val int) string {
+			if val%7 == 0 {
+				time.Sleep(time.Microsecond * 100)
+			}
+			return gg.String(val)
+		})
+
+		vals, err := gg.PipeCollect(pipe, out)
+		gtest.NoErr(err, grepr.String(conf))
+
+		if !ordered {
+			sort.Slice(vals, func(one, two int) bool {
+				return gg.ParseTo[int](vals[one]) < gg.ParseTo[int](vals[two])
+			})
+		}
+		gtest.Equal(vals, exp, grepr.String(conf))
+	}
+
+	test(gg.PipeConf{}, true)
+	test(gg.PipeConf{Buf: 4}, true)
+	test(gg.PipeConf{Par: 1, Ordered: true}, true)
+	test(gg.PipeConf{Par: 4, Ordered: true}, true)
+	test(gg.PipeConf{Par: 4, Ordered: true, Buf: 8}, true)
+	test(gg.PipeConf{Par: 4}, false)
+	test(gg.PipeConf{Par: 4, Buf: 8}, false)
+}
+
+func TestPipeMap_Par(t *testing.T) {
+	defer gtest.Catch(t)
+
+	for _, conf := range []gg.PipeConf{{Par: 3}, {Par: 3, Ordered: true}} {
+		var peak testConcPeak
+
+		pipe := gg.NewPipe(nil)
+		out := gg.PipeMap(pipe, gg.PipeFrom(pipe, gg.Span(32)), conf, func(_ context.Context, val int) int {
+			peak.Run(time.Millisecond)
+			return val
+		})
+
+		vals, err := gg.PipeCollect(pipe, out)
+		gtest.NoErr(err)
+		gtest.Len(vals, 32)
+		gtest.LessEqPrim(peak.peak.Load(), int64(conf.Par), grepr.String(conf))
+		gtest.LessPrim(int64(1), peak.peak.Load(), `must run in parallel`, grepr.String(conf))
+	}
+}
+
+func TestPipeFilter(t *testing.T) {
+	defer gtest.Catch(t)
+
+	for _, conf := range []gg.PipeConf{{}, {Par: 4, Ordered: true}} {
+		pipe := gg.NewPipe(nil)
+		out := gg.PipeFilter(pipe, gg.PipeFrom(pipe, gg.Span(10)), conf, func(_ context.Context, val int) bool {
+			return val%2 == 0
+		})
+
+		vals, err := gg.PipeCollect(pipe, out)
+		gtest.NoErr(err)
+		gtest.Equal(vals, []int{0, 2, 4, 6, 8})
+	}
+}
+
+func TestPipeBatch(t *testing.T) {
+	defer gtest.Catch(t)
+
+	test := func(src []int, size int, exp [][]int) {
+		pipe := gg.NewPipe(nil)
+		vals, err := gg.PipeCollect(pipe, gg.PipeBatch(pipe, gg.PipeFrom(pipe, src), size))
+		gtest.NoErr(err)
+		gtest.Equal(vals, exp)
+	}
+
+	test(nil, 2, nil)
+	test(gg.Span(3), 0, [][]int{{0}, {1}, {2}})
+	test(gg.Span(4), 2, [][]int{{0, 1}, {2, 3}})
+	test(gg.Span(5), 2, [][]int{{0, 1}, {2, 3}, {4}})
+	test(gg.Span(3), 8, [][]int{{0, 1, 2}})
+}
+
+func TestPipeMerge_PipeFanOut(t *testing.T) {
+	defer gtest.Catch(t)
+
+	pipe := gg.NewPipe(nil)
+	outs := gg.PipeFanOut(pipe, gg.PipeFrom(pipe, gg.Span(100)), 4)
+	gtest.Len(outs, 4)
+
+	var counts [4]atomic.Int64
+	var mapped []<-chan int
+
+	for ind, out := range outs {
+		ind := ind
+		mapped = append(mapped, gg.PipeMap(pipe, out, gg.PipeConf{}, func(_ context.Context, val int) int {
+			counts[ind].Add(1)
+			return val
+		}))
+	}
+
+	vals, err := gg.PipeCollect(pipe, gg.PipeMerge(pipe, mapped...))
+	gtest.NoErr(err)
+
+	sort.Ints(vals)
+	gtest.Equal(vals, gg.Span(100))
+
+	var total int64
+	for ind := range counts {
+		total += counts[ind].Load()
+	}
+	gtest.Eq(total, 100)
+
+	pipe = gg.NewPipe(nil)
+	vals, err = gg.PipeCollect(pipe, gg.PipeMerge[int](pipe))
+	gtest.NoErr(err)
+	gtest.Zero(vals)
+}
+
+func TestPipeGen(t *testing.T) {
+	defer gtest.Catch(t)
+
+	pipe := gg.NewPipe(nil)
+	out := gg.PipeGen(pipe, func(_ context.Context, send func(int) bool) {
+		for ind := 0; ; ind++ {
+			if !send(ind) {
+				return
+			}
+		}
+	})
+
+	var vals []int
+	for val := range out {
+		vals = append(vals, val)
+		if len(vals) >= 3 {
+			break
+		}
+	}
+
+	pipe.Stop()
+	gtest.NoErr(pipe.Wait(), `stopping must not be an error`)
+	gtest.Equal(vals, []int{0, 1, 2})
+}
+
+func TestPipe_panic(t *testing.T) {
+	defer gtest.Catch(t)
+
+	test := func(conf gg.PipeConf) {
+		pipe := gg.NewPipe(nil)
+
+		var ctx context.Context
+		out := gg.PipeMap(pipe, gg.PipeFrom(pipe, gg.Span(1000)), conf, func(fnCtx context.Context, val int) int {
+			if val == 10 {
+				ctx = fnCtx
+				panic(testErrUntracedA)
+			}
+			return val
+		})
+
+		vals, err := gg.PipeCollect(pipe, out)
+		testWrappedErr(err, testErrUntracedA)
+		gtest.LessPrim(len(vals), 1000, grepr.String(conf))
+		gtest.ErrIs(ctx.Err(), context.Canceled, `failure must cancel the pipe's context`)
+	}
+
+	test(gg.PipeConf{})
+	test(gg.PipeConf{Par: 4})
+	test(gg.PipeConf{Par: 4, Ordered: true})
+
+	pipe := gg.NewPipe(nil)
+	err := gg.PipeEach(pipe, gg.PipeFrom(pipe, gg.Span(10)), func(_ context.Context, val int) {
+		if val == 2 {
+			panic(testErrTraced1)
+		}
+	})
+	testWrappedErr(err, testErrTraced1)
+}
+
+func TestPipe_ctx(t *testing.T) {
+	defer gtest.Catch(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pipe := gg.NewPipe(ctx)
+
+	out := gg.PipeMap(pipe, gg.PipeFrom(pipe, gg.Span(1000)), gg.PipeConf{Par: 4, Ordered: true}, func(_ context.Context, val int) int {
+		if val == 10 {
+			cancel()
+		}
+		return val
+	})
+
+	vals, err := gg.PipeCollect(pipe, out)
+	gtest.ErrIs(err, context.Canceled)
+	gtest.True(gg.IsErrTraced(err))
+	gtest.LessPrim(len(vals), 1000)
+}
+
+func TestPipe_early_stop(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var done atomic.Int64
+
+	pipe := gg.NewPipe(nil)
+	src := gg.PipeFrom(pipe, gg.Span(1000))
+
+	unordered := gg.PipeMap(pipe, src, gg.PipeConf{Par: 4}, func(_ context.Context, val int) int {
+		defer done.Add(1)
+		return val
+	})
+
+	ordered := gg.PipeMap(pipe, unordered, gg.PipeConf{Par: 4, Ordered: true}, func(_ context.Context, val int) int {
+		return val
+	})
+
+	outs := gg.PipeFanOut(pipe, gg.PipeBatch(pipe, ordered, 2), 2)
+	<-outs[0]
+
+	pipe.Stop()
+	gtest.NoErr(pipe.Wait(), `all goroutines must terminate`)
+	gtest.LessPrim(done.Load(), int64(1000))
+}
+
+func TestPipe_no_calls_after_failure(t *testing.T) {
+	defer gtest.Catch(t)
+
+	// Buffered and closed, so that every receive is always ready.
+	makeSrc := func() chan int {
+		out := make(chan int, 100)
+		for _, val := range gg.Span(100) {
+			out <- val
+		}
+		close(out)
+		return out
+	}
+
+	test := func(conf gg.PipeConf) {
+		var count atomic.Int64
+		pipe := gg.NewPipe(nil)
+
+		out := gg.PipeMap(pipe, makeSrc(), conf, func(context.Context, int) int {
+			count.Add(1)
+			panic(testErrUntracedA)
+		})
+
+		_, err := gg.PipeCollect(pipe, out)
+		testWrappedErr(err, testErrUntracedA)
+		gtest.LessEqPrim(count.Load(), int64(gg.MaxPrim2(conf.Par, 1)), grepr.String(conf))
+	}
+
+	test(gg.PipeConf{})
+	test(gg.PipeConf{Par: 4})
+	test(gg.PipeConf{Par: 4, Ordered: true})
+
+	var count int
+	pipe := gg.NewPipe(nil)
+	err := gg.PipeEach(pipe, makeSrc(), func(context.Context, int) {
+		count++
+		panic(testErrUntracedA)
+	})
+	testWrappedErr(err, testErrUntracedA)
+	gtest.Eq(count, 1)
+}
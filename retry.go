@@ -0,0 +1,353 @@
+package gg
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+/*
+Determines the delay before the next attempt in `Retry` and similar functions.
+The attempt number starts at 1 and indicates how many attempts have failed so
+far. Implemented by `BackoffFixed`, `BackoffExp`, `BackoffJitter`.
+*/
+type Backoff interface {
+	Delay(attempt int) time.Duration
+}
+
+// Implementation of `Backoff` which always returns the same delay.
+type BackoffFixed time.Duration
+
+// Implement `Backoff`.
+func (self BackoffFixed) Delay(int) time.Duration { return time.Duration(self) }
+
+/*
+Implementation of `Backoff` where delays grow exponentially: `.Min` for the
+first attempt, then multiplied by `.Mul` for each subsequent attempt, up to
+`.Max` when positive. If `.Mul` is not above 1, the multiplier is 2.
+*/
+type BackoffExp struct {
+	Min time.Duration
+	Max time.Duration
+	Mul float64
+}
+
+// Implement `Backoff`.
+func (self BackoffExp) Delay(attempt int) time.Duration {
+	mul := self.Mul
+	if !(mul > 1) {
+		mul = 2
+	}
+
+	lim := time.Duration(math.MaxInt64)
+	if self.Max > 0 {
+		lim = self.Max
+	}
+
+	out := float64(self.Min) * math.Pow(mul, float64(MaxPrim2(attempt, 1)-1))
+	if !(out < float64(lim)) {
+		return lim
+	}
+	return time.Duration(out)
+}
+
+/*
+Implementation of `Backoff` which randomizes the delays of another backoff,
+to avoid synchronized retries from multiple clients. Each delay is reduced by a
+random amount up to `.Frac` of the delay, where `.Frac` is between 0 and 1.
+For example, 0.5 produces delays between 50% and 100% of the original, while 1
+produces "full jitter". `.Rand` must return numbers in the range `[0,1)`; if
+nil, "math/rand".Float64 is used. Tests may provide a deterministic source.
+*/
+type BackoffJitter struct {
+	Backoff Backoff
+	Frac    float64
+	Rand    func() float64
+}
+
+// Implement `Backoff`.
+func (self BackoffJitter) Delay(attempt int) time.Duration {
+	if self.Backoff == nil {
+		return 0
+	}
+
+	out := self.Backoff.Delay(attempt)
+	frac := MinPrim2(MaxPrim2(self.Frac, 0), 1)
+	if frac == 0 || out <= 0 {
+		return out
+	}
+
+	rnd := self.Rand
+	if rnd == nil {
+		rnd = rand.Float64
+	}
+	return out - time.Duration(float64(out)*frac*rnd())
+}
+
+/*
+Default limit on the count of attempts, used by `RetryPolicy` when neither
+`.MaxAttempts` nor `.MaxElapsed` is set.
+*/
+const RetryAttemptsDefault = 5
+
+/*
+Max count of errors of the latest attempts retained by `Retry` and similar
+functions, in addition to the error of the first attempt. Errors of attempts
+in between are dropped.
+*/
+const retryErrsLast = 8
+
+/*
+Configures `Retry` and similar functions. The zero value retries immediately,
+up to `RetryAttemptsDefault` attempts, until the function succeeds, the error
+is non-retryable, or the context is done.
+*/
+type RetryPolicy struct {
+	// Delay between attempts. If nil, there's no delay.
+	Backoff Backoff
+
+	/**
+	When positive, limits the total count of attempts. When neither this nor
+	`.MaxElapsed` is positive, `RetryAttemptsDefault` is used.
+	*/
+	MaxAttempts int
+
+	/**
+	When positive, limits the total time spent, including delays. An attempt
+	is not started if the delay before it would exceed the limit.
+	*/
+	MaxElapsed time.Duration
+
+	/**
+	Decides whether an error is retryable. If nil, `IsErrRetryableDefault` is
+	used. See `RetryIs`, `RetryIsNot`, `RetrySome` for building predicates.
+	*/
+	Test func(error) bool
+
+	// Source of time and delays. If nil, `ClockReal` is used.
+	Clock Clock
+}
+
+func (self RetryPolicy) test(err error) bool {
+	if self.Test != nil {
+		return self.Test(err)
+	}
+	return IsErrRetryableDefault(err)
+}
+
+func (self RetryPolicy) maxAttempts() int {
+	if self.MaxAttempts <= 0 && self.MaxElapsed <= 0 {
+		return RetryAttemptsDefault
+	}
+	return self.MaxAttempts
+}
+
+func (self RetryPolicy) delay(attempt int) time.Duration {
+	if self.Backoff == nil {
+		return 0
+	}
+	return self.Backoff.Delay(attempt)
+}
+
+/*
+Default retryability predicate used by `RetryPolicy`. True for all errors
+except those whose code is classified as `ErrClassPermanent`, see `ErrCodeReg`.
+*/
+func IsErrRetryableDefault(err error) bool { return !IsErrPermanent(err) }
+
+/*
+Returns a retryability predicate which is true if the error matches any of
+the given errors via `errors.Is`.
+*/
+func RetryIs(src ...error) func(error) bool {
+	return func(err error) bool {
+		return Some(src, func(val error) bool { return errors.Is(err, val) })
+	}
+}
+
+/*
+Returns a retryability predicate which is true if the error doesn't match any
+of the given errors via `errors.Is`.
+*/
+func RetryIsNot(src ...error) func(error) bool {
+	test := RetryIs(src...)
+	return func(err error) bool { return !test(err) }
+}
+
+/*
+Returns a retryability predicate which is true if any error in the error's
+chain, including errors in `Errs`, satisfies the given function. Uses
+`ErrSome`. Example:
+
+	gg.RetrySome(gg.ErrCode(`unavailable`).Match)
+*/
+func RetrySome(fun func(error) bool) func(error) bool {
+	return func(err error) bool { return ErrSome(err, fun) }
+}
+
+/*
+Calls the given function, retrying on panics according to the given policy,
+until the function succeeds. On final failure, panics with the error described
+in `RetryCatch`. If the context is nil, `context.Background` is used.
+*/
+func Retry(ctx context.Context, pol RetryPolicy, fun func(context.Context)) {
+	_, errs, count := retry(ctx, pol, fun, retryCatch)
+	if errs != nil {
+		panic(retryErrAt(errs, count, 1))
+	}
+}
+
+/*
+Calls the given function, retrying on panics according to the given policy,
+until the function succeeds, returning the result. On final failure, panics
+with the error described in `RetryCatch`.
+If the context is nil, `context.Background` is used.
+*/
+func Retry1[A any](ctx context.Context, pol RetryPolicy, fun func(context.Context) A) A {
+	out, errs, count := retry(ctx, pol, fun, Catch11[context.Context, A])
+	if errs != nil {
+		panic(retryErrAt(errs, count, 1))
+	}
+	return out
+}
+
+/*
+Calls the given function, retrying on panics according to the given policy,
+until the function succeeds. On final failure, returns an `Err` with a stack
+trace, whose cause is `Errs` containing the errors of the first attempt and of
+up to 8 latest attempts, with their traces. If the context was done, the last
+of them is the context's error.
+If the context is nil, `context.Background` is used.
+*/
+func RetryCatch(ctx context.Context, pol RetryPolicy, fun func(context.Context)) error {
+	_, errs, count := retry(ctx, pol, fun, retryCatch)
+	if errs != nil {
+		return retryErrAt(errs, count, 1)
+	}
+	return nil
+}
+
+/*
+Calls the given function, retrying on panics according to the given policy,
+until the function succeeds, returning the result. On final failure, returns
+the error described in `RetryCatch`.
+If the context is nil, `context.Background` is used.
+*/
+func RetryCatch1[A any](ctx context.Context, pol RetryPolicy, fun func(context.Context) A) (A, error) {
+	out, errs, count := retry(ctx, pol, fun, Catch11[context.Context, A])
+	if errs != nil {
+		return out, retryErrAt(errs, count, 1)
+	}
+	return out, nil
+}
+
+/*
+Calls the given function, retrying on errors according to the given policy,
+until the function returns nil. On final failure, returns the error described
+in `RetryCatch`. Errors returned by the function are idempotently traced.
+If the context is nil, `context.Background` is used.
+*/
+func RetryErr(ctx context.Context, pol RetryPolicy, fun func(context.Context) error) error {
+	_, errs, count := retry(ctx, pol, fun, retryErr)
+	if errs != nil {
+		return retryErrAt(errs, count, 1)
+	}
+	return nil
+}
+
+/*
+Calls the given function, retrying on errors according to the given policy,
+until the function returns a nil error, returning the result. On final failure,
+returns the error described in `RetryCatch`. Errors returned by the function
+are idempotently traced. If the context is nil, `context.Background` is used.
+*/
+func RetryErr1[A any](ctx context.Context, pol RetryPolicy, fun func(context.Context) (A, error)) (A, error) {
+	out, errs, count := retry(ctx, pol, fun, retryErr1[A])
+	if errs != nil {
+		return out, retryErrAt(errs, count, 1)
+	}
+	return out, nil
+}
+
+/*
+Returns the result of the first successful attempt, or the errors of the first
+and latest attempts along with the count of attempts. The errors may
+additionally include the context's error.
+*/
+func retry[Fun any, Out any](
+	ctx context.Context,
+	pol RetryPolicy,
+	fun Fun,
+	run func(Fun, context.Context) (Out, error),
+) (_ Out, errs Errs, count int) {
+	ctx = ctxOr(ctx)
+	clock := ClockOr(pol.Clock)
+	start := clock.Now()
+	maxAttempts := pol.maxAttempts()
+
+	for {
+		err := ctx.Err()
+		if err != nil {
+			return Zero[Out](), retryErrsAppend(errs, err), count
+		}
+
+		out, err := run(fun, ctx)
+		count++
+		if err == nil {
+			return out, nil, count
+		}
+		errs = retryErrsAppend(errs, err)
+
+		if !pol.test(err) || (maxAttempts > 0 && count >= maxAttempts) {
+			return Zero[Out](), errs, count
+		}
+
+		delay := pol.delay(count)
+		if pol.MaxElapsed > 0 && clock.Now().Add(delay).Sub(start) > pol.MaxElapsed {
+			return Zero[Out](), errs, count
+		}
+
+		err = clock.Sleep(ctx, delay)
+		if err != nil {
+			return Zero[Out](), retryErrsAppend(errs, err), count
+		}
+	}
+}
+
+/*
+Appends the error while keeping the first error and at most `retryErrsLast`
+latest errors, dropping the oldest of the rest.
+*/
+func retryErrsAppend(errs Errs, err error) Errs {
+	if len(errs) <= retryErrsLast {
+		return append(errs, err)
+	}
+	copy(errs[1:], errs[2:])
+	errs[len(errs)-1] = err
+	return errs
+}
+
+func retryCatch(fun func(context.Context), ctx context.Context) (_ struct{}, err error) {
+	return struct{}{}, Catch10(fun, ctx)
+}
+
+func retryErr(fun func(context.Context) error, ctx context.Context) (_ struct{}, err error) {
+	if fun != nil {
+		err = ErrTracedAt(fun(ctx), 1)
+	}
+	return
+}
+
+func retryErr1[A any](fun func(context.Context) (A, error), ctx context.Context) (out A, err error) {
+	if fun != nil {
+		out, err = fun(ctx)
+		err = ErrTracedAt(err, 1)
+	}
+	return
+}
+
+func retryErrAt(errs Errs, count, skip int) Err {
+	return Err{}.Msgf(`failed after %v attempts`, count).Caused(errs).TracedAt(skip + 1)
+}
@@ -0,0 +1,356 @@
+package gg_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+func TestBackoffFixed(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Eq(gg.BackoffFixed(time.Second).Delay(1), time.Second)
+	gtest.Eq(gg.BackoffFixed(time.Second).Delay(10), time.Second)
+}
+
+func TestBackoffExp(t *testing.T) {
+	defer gtest.Catch(t)
+
+	src := gg.BackoffExp{Min: time.Second, Max: time.Second * 10}
+	gtest.Eq(src.Delay(0), time.Second)
+	gtest.Eq(src.Delay(1), time.Second)
+	gtest.Eq(src.Delay(2), time.Second*2)
+	gtest.Eq(src.Delay(3), time.Second*4)
+	gtest.Eq(src.Delay(4), time.Second*8)
+	gtest.Eq(src.Delay(5), time.Second*10)
+	gtest.Eq(src.Delay(1000), time.Second*10)
+
+	src = gg.BackoffExp{Min: time.Second, Mul: 3}
+	gtest.Eq(src.Delay(3), time.Second*9)
+	gtest.Eq(src.Delay(1000), time.Duration(1<<63-1))
+}
+
+func TestBackoffJitter(t *testing.T) {
+	defer gtest.Catch(t)
+
+	rnd := func() float64 { return 0.5 }
+
+	gtest.Zero(gg.BackoffJitter{}.Delay(1))
+	gtest.Eq(gg.BackoffJitter{Backoff: gg.BackoffFixed(time.Second)}.Delay(1), time.Second)
+
+	gtest.Eq(
+		gg.BackoffJitter{Backoff: gg.BackoffFixed(time.Second), Frac: 0.5, Rand: rnd}.Delay(1),
+		time.Millisecond*750,
+	)
+
+	gtest.Eq(
+		gg.BackoffJitter{Backoff: gg.BackoffFixed(time.Second), Frac: 10, Rand: rnd}.Delay(1),
+		time.Millisecond*500,
+	)
+
+	src := gg.BackoffJitter{Backoff: gg.BackoffFixed(time.Second), Frac: 1}
+	for range gg.Span(64) {
+		val := src.Delay(1)
+		gtest.True(val > 0 && val <= time.Second)
+	}
+}
+
+func TestClockFake(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var clock gg.ClockFake
+	gtest.Zero(clock.Now())
+
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock.SetNow(start)
+	gtest.Eq(clock.Now(), start)
+
+	clock.Advance(time.Second)
+	gtest.NoErr(clock.Sleep(context.Background(), time.Minute))
+	gtest.Eq(clock.Now(), start.Add(time.Second+time.Minute))
+	gtest.Equal(clock.Sleeps(), []time.Duration{time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	gtest.ErrIs(clock.Sleep(ctx, time.Minute), context.Canceled)
+	gtest.Eq(clock.Now(), start.Add(time.Second+time.Minute))
+}
+
+func TestClockReal(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.NoErr(gg.ClockReal{}.Sleep(context.Background(), 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	gtest.ErrIs(gg.ClockReal{}.Sleep(ctx, time.Hour), context.Canceled)
+}
+
+func TestRetryErr(t *testing.T) {
+	defer gtest.Catch(t)
+
+	ctx := context.Background()
+
+	t.Run(`success`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var clock gg.ClockFake
+		var count int
+
+		err := gg.RetryErr(ctx, gg.RetryPolicy{
+			Backoff:     gg.BackoffExp{Min: time.Second},
+			MaxAttempts: 5,
+			Clock:       &clock,
+		}, func(context.Context) error {
+			count++
+			if count < 3 {
+				return io.EOF
+			}
+			return nil
+		})
+
+		gtest.NoErr(err)
+		gtest.Eq(count, 3)
+		gtest.Equal(clock.Sleeps(), []time.Duration{time.Second, time.Second * 2})
+	})
+
+	t.Run(`max_attempts`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var clock gg.ClockFake
+		var count int
+
+		err := gg.RetryErr(ctx, gg.RetryPolicy{
+			Backoff:     gg.BackoffFixed(time.Second),
+			MaxAttempts: 3,
+			Clock:       &clock,
+		}, func(context.Context) error {
+			count++
+			return io.EOF
+		})
+
+		gtest.Eq(count, 3)
+		gtest.Equal(clock.Sleeps(), []time.Duration{time.Second, time.Second})
+		gtest.ErrStr(`failed after 3 attempts: multiple errors; EOF; EOF; EOF`, err)
+		gtest.ErrIs(err, io.EOF)
+		gtest.True(gg.IsErrTraced(err))
+
+		errs := errors.Unwrap(err).(gg.Errs)
+		gtest.Len(errs, 3)
+		gtest.True(gg.Every(errs, gg.IsErrTraced))
+	})
+
+	t.Run(`max_elapsed`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var clock gg.ClockFake
+		var count int
+
+		err := gg.RetryErr(ctx, gg.RetryPolicy{
+			Backoff:    gg.BackoffExp{Min: time.Second},
+			MaxElapsed: time.Second * 10,
+			Clock:      &clock,
+		}, func(context.Context) error {
+			count++
+			return io.EOF
+		})
+
+		// Delays 1+2+4 fit, while the next 8 would exceed the limit.
+		gtest.Eq(count, 4)
+		gtest.Equal(clock.Sleeps(), []time.Duration{time.Second, time.Second * 2, time.Second * 4})
+		gtest.ErrStr(`failed after 4 attempts`, err)
+	})
+
+	t.Run(`default_attempts`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var clock gg.ClockFake
+		var count int
+
+		err := gg.RetryErr(ctx, gg.RetryPolicy{Clock: &clock}, func(context.Context) error {
+			count++
+			return io.EOF
+		})
+
+		gtest.Eq(count, gg.RetryAttemptsDefault)
+		gtest.Len(clock.Sleeps(), gg.RetryAttemptsDefault-1)
+		gtest.ErrStr(`failed after 5 attempts`, err)
+	})
+
+	t.Run(`errs_bounded`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var count int
+
+		err := gg.RetryErr(ctx, gg.RetryPolicy{
+			MaxAttempts: 20,
+			Clock:       new(gg.ClockFake),
+		}, func(context.Context) error {
+			count++
+			return gg.Errf(`attempt %v`, count)
+		})
+
+		gtest.Eq(count, 20)
+		gtest.ErrStr(`failed after 20 attempts`, err)
+
+		errs := errors.Unwrap(err).(gg.Errs)
+		gtest.Len(errs, 9)
+		gtest.Equal(
+			gg.Map(errs, func(err error) string { return err.Error() }),
+			[]string{
+				`attempt 1`,
+				`attempt 13`, `attempt 14`, `attempt 15`, `attempt 16`,
+				`attempt 17`, `attempt 18`, `attempt 19`, `attempt 20`,
+			},
+		)
+	})
+
+	t.Run(`non_retryable`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var count int
+		fun := func(context.Context) error {
+			count++
+			if count == 1 {
+				return io.ErrUnexpectedEOF
+			}
+			return gg.Errf(`permanent`, testErrCodePerm)
+		}
+
+		err := gg.RetryErr(ctx, gg.RetryPolicy{MaxAttempts: 5, Clock: new(gg.ClockFake)}, fun)
+		gtest.Eq(count, 2)
+		gtest.ErrIs(err, testErrCodePerm)
+
+		count = 0
+		err = gg.RetryErr(ctx, gg.RetryPolicy{
+			MaxAttempts: 5,
+			Test:        gg.RetryIsNot(io.ErrUnexpectedEOF),
+		}, fun)
+		gtest.Eq(count, 1)
+		gtest.ErrIs(err, io.ErrUnexpectedEOF)
+	})
+
+	t.Run(`context`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		ctx, cancel := context.WithCancel(ctx)
+		var count int
+
+		err := gg.RetryErr(ctx, gg.RetryPolicy{MaxAttempts: 5}, func(context.Context) error {
+			count++
+			if count == 2 {
+				cancel()
+			}
+			return io.EOF
+		})
+
+		gtest.Eq(count, 2)
+		gtest.ErrStr(`failed after 2 attempts: multiple errors; EOF; EOF; context canceled`, err)
+		gtest.ErrIs(err, context.Canceled)
+
+		count = 0
+		gtest.ErrIs(gg.RetryErr(ctx, gg.RetryPolicy{}, nil), context.Canceled)
+		gtest.Zero(count)
+	})
+}
+
+func TestRetryErr1(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var count int
+	out, err := gg.RetryErr1(context.Background(), gg.RetryPolicy{MaxAttempts: 3}, func(context.Context) (int, error) {
+		count++
+		if count < 2 {
+			return 0, io.EOF
+		}
+		return count * 10, nil
+	})
+
+	gtest.NoErr(err)
+	gtest.Eq(out, 20)
+}
+
+func TestRetryCatch(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var count int
+	err := gg.RetryCatch(context.Background(), gg.RetryPolicy{MaxAttempts: 2}, func(context.Context) {
+		count++
+		panic(io.EOF)
+	})
+
+	gtest.Eq(count, 2)
+	gtest.ErrStr(`failed after 2 attempts: multiple errors; EOF; EOF`, err)
+	gtest.True(gg.Every(errors.Unwrap(err).(gg.Errs), gg.IsErrTraced))
+}
+
+func TestRetry1(t *testing.T) {
+	defer gtest.Catch(t)
+
+	ctx := context.Background()
+	pol := gg.RetryPolicy{
+		MaxAttempts: 3,
+		Test:        gg.RetrySome(testErrCodeRetry.Match),
+	}
+
+	var count int
+	gtest.Eq(
+		gg.Retry1(ctx, pol, func(context.Context) int {
+			count++
+			if count < 3 {
+				panic(gg.Errf(`unavailable`, testErrCodeRetry))
+			}
+			return count
+		}),
+		3,
+	)
+
+	gtest.PanicStr(`failed after 1 attempts: EOF`, func() {
+		gg.Retry(ctx, pol, func(context.Context) { panic(io.EOF) })
+	})
+
+	out, err := gg.RetryCatch1(ctx, pol, func(context.Context) int { panic(io.EOF) })
+	gtest.Zero(out)
+	gtest.ErrIs(err, io.EOF)
+}
+
+func TestRetryIs(t *testing.T) {
+	defer gtest.Catch(t)
+
+	test := gg.RetryIs(io.EOF, io.ErrUnexpectedEOF)
+	gtest.True(test(io.EOF))
+	gtest.True(test(gg.Wrap(io.ErrUnexpectedEOF, `wrapped`)))
+	gtest.False(test(io.ErrClosedPipe))
+	gtest.False(gg.RetryIs()(io.EOF))
+
+	gtest.True(gg.RetrySome(testErrCodeRetry.Match)(gg.Errs{io.EOF, gg.Errf(`one`, testErrCodeRetry)}))
+	gtest.False(gg.RetrySome(testErrCodeRetry.Match)(io.EOF))
+
+	gtest.True(gg.IsErrRetryableDefault(io.EOF))
+	gtest.False(gg.IsErrRetryableDefault(gg.Wrap(testErrCodePerm, `wrapped`)))
+}
+
+func TestRetry_nil_ctx(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var clock gg.ClockFake
+	pol := gg.RetryPolicy{
+		Backoff:     gg.BackoffFixed(time.Second),
+		MaxAttempts: 2,
+		Clock:       &clock,
+	}
+
+	//nolint:staticcheck
+	gtest.ErrStr(`failed after 2 attempts`, gg.RetryErr(nil, pol, func(ctx context.Context) error {
+		gtest.NotZero(ctx)
+		return io.EOF
+	}))
+
+	//nolint:staticcheck
+	gtest.Eq(gg.Retry1(nil, pol, func(context.Context) int { return 10 }), 10)
+}
@@ -25,47 +25,32 @@ for dynamic variables.
 
 Valid SIDs start at 1; 0 should be considered unset / missing.
 
-This was implemented out of horrified amazement at the idea,
-and probably has no real use case.
+This was implemented out of horrified amazement at the idea. The main use is
+the SID backend of dynamic variables: [DynBackendSid].
 */
 func Sid() (out uint64) {
 	/**
 	In our testing, this is always stack-allocated. We only pass this to
 	`runtime.Callers` where it doesn't escape. May vary by Go version.
-	Last tested in Go 1.24.0.
+	Last tested in Go 1.24.0. Stacks deeper than this use a heap-allocated
+	buffer; see `sidCallers`.
 	*/
 	buf := make([]uintptr, 64)
 
 	// Skip this PC and `runtime.Callers` itself.
-	off := 2
-
-	for {
-		size := runtime.Callers(off, buf)
-		if size <= 0 {
-			break
+	for _, addr := range sidCallers(buf, 2) {
+		if addr == 0 || addr == sidEndPc {
+			return
 		}
 
-		// Linter is bugged.
-		// nolint ineffassign
-		off += size
-
-		for _, addr := range buf[:size] {
-			if addr == 0 || addr == sidEndPc {
-				return
-			}
-
-			dig, ok := sidPcsToDigits[addr]
-			if !ok {
-				continue
-			}
-
-			out <<= sidDigitBits
-			out += uint64(dig)
+		dig, ok := sidPcsToDigits[addr]
+		if !ok {
+			continue
 		}
 
-		break
+		out <<= sidDigitBits
+		out += uint64(dig)
 	}
-
 	return
 }
 
@@ -153,6 +138,24 @@ func sidFromPool() (_ uint64, _ bool) {
 	return sid, true
 }
 
+/*
+Returns the PCs of the caller's stack, skipping the given amount of frames like
+`runtime.Callers`. Each call to `runtime.Callers` walks the stack from the top,
+even when skipping frames, so instead of walking the stack in chunks, we grow
+the buffer until the whole stack fits, doubling its size each time. The total
+cost is linear in the depth of the stack.
+*/
+func sidCallers(buf []uintptr, skip int) []uintptr {
+	for {
+		// Also skip this function.
+		size := runtime.Callers(skip+1, buf)
+		if size < len(buf) {
+			return buf[:size]
+		}
+		buf = make([]uintptr, len(buf)*2)
+	}
+}
+
 type sidFunc = func(uint64, func())
 
 const sidDigitBits = 4
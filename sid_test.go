@@ -243,3 +243,21 @@ func Test_github_com_jtolio_gls(t *testing.T) {
 	gro.Wait()
 }
 */
+
+func TestSid_deep_stack(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gg.WithGivenSid(0x123456789abcdef, func() {
+		gtest.Eq(deepSid(0), 0x123456789abcdef)
+		gtest.Eq(deepSid(63), 0x123456789abcdef)
+		gtest.Eq(deepSid(64), 0x123456789abcdef)
+		gtest.Eq(deepSid(256), 0x123456789abcdef)
+	})
+}
+
+func deepSid(depth int) uint64 {
+	if depth > 0 {
+		return deepSid(depth - 1)
+	}
+	return gg.Sid()
+}
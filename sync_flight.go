@@ -0,0 +1,151 @@
+package gg
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+Keyed deduplication of concurrent work, also known as "singleflight". When
+multiple goroutines request the same key at the same time, only the first one
+performs the work, while the others wait for its result. Unlike `Mem` and
+`Cache`, the result is not retained: once the work is done, the next request for
+the same key performs it again. Combine with a cache if retention is desired.
+
+If the function panics, the panic is converted to an error with a stack trace,
+which is shared by all callers waiting for that key, with their own traces
+added on top. See `Flight.Do`, `Flight.DoCatch`, `Flight.DoCtx`.
+
+The zero value is ready to use. Contains a synchronization primitive and must
+not be copied after first use.
+*/
+type Flight[Key comparable, Val any] struct {
+	lock  sync.Mutex
+	calls map[Key]*flightCall[Val]
+}
+
+/*
+Returns the result of calling the given function for the given key. If a call
+for this key is already in progress, waits for it and returns its result,
+without calling the given function. If the call panics, all callers panic with
+the same error, each with its own stack trace added.
+*/
+func (self *Flight[Key, Val]) Do(key Key, fun func() Val) Val {
+	val, err := self.do(key, fun)
+	TryErr(WrapTracedAt(err, 1))
+	return val
+}
+
+/*
+Like `Flight.Do`, but instead of panicking, returns the error. Each caller
+receives the same underlying error, with its own stack trace added.
+*/
+func (self *Flight[Key, Val]) DoCatch(key Key, fun func() Val) (Val, error) {
+	val, err := self.do(key, fun)
+	return val, WrapTracedAt(err, 1)
+}
+
+/*
+Like `Flight.DoCatch`, but stops waiting when the given context is done,
+returning the context's error. If the context is nil, `context.Background` is
+used. In this mode, the function always runs on a new goroutine, so that every
+caller, including the one which started the call, is able to stop waiting. The
+call is never interrupted: it keeps running, and its result is delivered to
+other callers which are still waiting. If the context is done before the call,
+returns its error without starting or joining a call.
+*/
+func (self *Flight[Key, Val]) DoCtx(ctx context.Context, key Key, fun func() Val) (Val, error) {
+	ctx = ctxOr(ctx)
+
+	err := ctx.Err()
+	if err != nil {
+		return Zero[Val](), WrapTracedAt(err, 1)
+	}
+
+	call, leader := self.join(key)
+	if leader {
+		go self.run(key, call, fun)
+	}
+
+	select {
+	case <-call.done:
+		return call.val, WrapTracedAt(call.err, 1)
+	case <-ctx.Done():
+		return Zero[Val](), WrapTracedAt(ctx.Err(), 1)
+	}
+}
+
+/*
+Forgets the call for the given key, if any, which is currently in progress. The
+call keeps running, and callers which are already waiting for it still receive
+its result, but subsequent requests for this key start a new call instead of
+joining the previous one. Useful when the in-flight work is known to produce
+outdated results.
+*/
+func (self *Flight[Key, _]) Forget(key Key) {
+	defer Lock(&self.lock).Unlock()
+	delete(self.calls, key)
+}
+
+// True if a call for the given key is currently in progress.
+func (self *Flight[Key, _]) Has(key Key) bool {
+	defer Lock(&self.lock).Unlock()
+	return self.calls[key] != nil
+}
+
+func (self *Flight[Key, Val]) do(key Key, fun func() Val) (Val, error) {
+	call, leader := self.join(key)
+	if leader {
+		self.run(key, call, fun)
+	} else {
+		<-call.done
+	}
+	return call.val, call.err
+}
+
+/*
+Returns the in-flight call for the given key, registering a new one if
+necessary. True means the new call must be started by the caller.
+*/
+func (self *Flight[Key, Val]) join(key Key) (*flightCall[Val], bool) {
+	defer Lock(&self.lock).Unlock()
+
+	call := self.calls[key]
+	if call != nil {
+		return call, false
+	}
+
+	call = &flightCall[Val]{done: make(chan struct{})}
+	MapInit(&self.calls)[key] = call
+	return call, true
+}
+
+func (self *Flight[Key, Val]) run(key Key, call *flightCall[Val], fun func() Val) {
+	defer self.finish(key, call)
+	defer Rec(&call.err)
+	if fun != nil {
+		call.val = fun()
+	}
+}
+
+/*
+Unregisters the call, unless it was forgotten and possibly replaced by another
+call for the same key, then wakes up the waiters.
+*/
+func (self *Flight[Key, Val]) finish(key Key, call *flightCall[Val]) {
+	defer close(call.done)
+	defer Lock(&self.lock).Unlock()
+	if self.calls[key] == call {
+		delete(self.calls, key)
+	}
+}
+
+/*
+The fields `.val` and `.err` are written only by the goroutine running the call,
+before closing `.done`, and may be read by others only after that.
+*/
+type flightCall[Val any] struct {
+	done chan struct{}
+	val  Val
+	err  error
+}
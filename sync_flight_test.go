@@ -0,0 +1,215 @@
+package gg_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+/*
+Context which reports the first call to `.Done`. `Flight.DoCtx` calls it only
+after joining a call, which allows tests to wait until a caller has joined,
+without sleeping.
+*/
+type testFlightCtx struct {
+	context.Context
+	once   sync.Once
+	joined chan struct{}
+}
+
+func newTestFlightCtx() *testFlightCtx {
+	return &testFlightCtx{Context: context.Background(), joined: make(chan struct{})}
+}
+
+func (self *testFlightCtx) Done() <-chan struct{} {
+	self.once.Do(func() { close(self.joined) })
+	return self.Context.Done()
+}
+
+/*
+Starts the given amount of callers for the given key. The first caller starts
+the call via the given function. The call blocks until the returned function
+is called. The other callers join it via `Flight.DoCtx`. Returns after all
+callers are in the same call. The returned function releases the call, waits
+for all callers, and returns their results.
+*/
+func testFlightCalls(
+	flight *gg.Flight[string, int],
+	key string,
+	count int,
+	call func(func() int) (int, error),
+) (*atomic.Int64, func() ([]int, []error)) {
+	var calls atomic.Int64
+	var gro sync.WaitGroup
+	started := make(chan struct{}, count)
+	release := make(chan struct{})
+
+	vals := make([]int, count)
+	errs := make([]error, count)
+
+	fun := func() int {
+		started <- struct{}{}
+		<-release
+		return int(calls.Add(1)) * 10
+	}
+
+	gro.Add(1)
+	go func() {
+		defer gro.Done()
+		vals[0], errs[0] = call(fun)
+	}()
+	<-started
+
+	for ind := 1; ind < count; ind++ {
+		ind := ind
+		ctx := newTestFlightCtx()
+		gro.Add(1)
+		go func() {
+			defer gro.Done()
+			vals[ind], errs[ind] = flight.DoCtx(ctx, key, fun)
+		}()
+		<-ctx.joined
+	}
+
+	return &calls, func() ([]int, []error) {
+		close(release)
+		gro.Wait()
+		return vals, errs
+	}
+}
+
+func TestFlight_Do(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var flight gg.Flight[string, int]
+
+	calls, wait := testFlightCalls(&flight, `one`, 8, func(fun func() int) (int, error) {
+		return flight.Do(`one`, fun), nil
+	})
+
+	gtest.True(flight.Has(`one`))
+	gtest.False(flight.Has(`two`))
+	gtest.Eq(flight.Do(`two`, func() int { return 20 }), 20, `keys must be independent`)
+
+	vals, _ := wait()
+	gtest.Equal(vals, []int{10, 10, 10, 10, 10, 10, 10, 10})
+	gtest.Eq(calls.Load(), 1)
+	gtest.False(flight.Has(`one`))
+
+	gtest.Eq(flight.Do(`one`, func() int { return 30 }), 30, `results must not be retained`)
+	gtest.Zero(flight.Do(`one`, nil))
+}
+
+func TestFlight_DoCatch_panic(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var flight gg.Flight[string, int]
+
+	calls, wait := testFlightCalls(&flight, `one`, 4, func(fun func() int) (int, error) {
+		return flight.DoCatch(`one`, func() int {
+			fun()
+			panic(testErrUntracedA)
+		})
+	})
+
+	vals, errs := wait()
+	gtest.Eq(calls.Load(), 1)
+	gtest.Equal(vals, []int{0, 0, 0, 0})
+	testWrappedErrs(errs, []error{testErrUntracedA, testErrUntracedA, testErrUntracedA, testErrUntracedA})
+
+	gtest.PanicErrIs(testErrTraced0, func() {
+		flight.Do(`one`, func() int { panic(testErrTraced0) })
+	})
+	gtest.False(flight.Has(`one`), `failed calls must be unregistered`)
+}
+
+func TestFlight_DoCtx(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var flight gg.Flight[string, int]
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fun := func() int {
+		close(started)
+		<-release
+		return 10
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 2)
+
+	go func() {
+		_, err := flight.DoCtx(ctx, `one`, fun)
+		done <- err
+	}()
+	<-started
+
+	var val int
+	var err error
+	join := newTestFlightCtx()
+	go func() {
+		val, err = flight.DoCtx(join, `one`, fun)
+		done <- nil
+	}()
+	<-join.joined
+
+	cancel()
+	canceled := <-done
+	gtest.ErrIs(canceled, context.Canceled, `the caller which started the call must be able to stop waiting`)
+	gtest.True(gg.IsErrTraced(canceled))
+	gtest.True(flight.Has(`one`), `the call must keep running`)
+
+	close(release)
+	gtest.Zero(<-done)
+	gtest.NoErr(err)
+	gtest.Eq(val, 10)
+
+	val, err = flight.DoCtx(ctx, `one`, func() int { panic(`unreachable`) })
+	gtest.ErrIs(err, context.Canceled)
+	gtest.Zero(val)
+	gtest.False(flight.Has(`one`))
+
+	val, err = flight.DoCtx(nil, `one`, func() int { return 20 })
+	gtest.NoErr(err)
+	gtest.Eq(val, 20)
+}
+
+func TestFlight_Forget(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var flight gg.Flight[string, int]
+
+	calls, wait := testFlightCalls(&flight, `one`, 2, func(fun func() int) (int, error) {
+		return flight.Do(`one`, fun), nil
+	})
+
+	flight.Forget(`one`)
+	gtest.False(flight.Has(`one`))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	next := make(chan int, 1)
+	go func() {
+		next <- flight.Do(`one`, func() int {
+			close(started)
+			<-release
+			return 30
+		})
+	}()
+	<-started
+
+	vals, _ := wait()
+	gtest.Equal(vals, []int{10, 10}, `waiters of a forgotten call must receive its result`)
+	gtest.Eq(calls.Load(), 1)
+	gtest.True(flight.Has(`one`), `a forgotten call must not unregister its replacement`)
+
+	close(release)
+	gtest.Eq(<-next, 30)
+	gtest.False(flight.Has(`one`))
+
+	flight.Forget(`missing`)
+}
@@ -1,17 +1,20 @@
 package gg
 
 import (
+	"path"
 	"runtime"
 	"strings"
 )
 
 // These variables control how stack traces are printed.
 var (
-	TraceTable     = true
-	TraceSkipLang  = true
-	TraceShortName = false
-	TraceBaseDir   = `` // Set to `Cwd()` for better traces.
-	TraceBufSize   = 64 // Default buffer capacity in `CaptureTrace`.
+	TraceTable        = true
+	TraceSkipLang     = true
+	TraceShortName    = false
+	TraceBaseDir      = ``            // Set to `Cwd()` for better traces.
+	TraceBufSize      = 64            // Default buffer capacity in `CaptureTrace`.
+	TraceFilter       = FrameFilter{} // Additional filtering; see `FrameFilter`.
+	TraceSnippetLines = 2             // Lines around each frame in `Trace.Snippet`.
 )
 
 // Free cast of `~[]~uintptr` to `Trace`.
@@ -106,6 +109,9 @@ Appends a representation of the trace similar to the default used by the Go
 runtime. Used internally by `.AppendIndentTo` if `TraceTable` is false.
 */
 func (self Trace) AppendIndentMultiTo(buf []byte, lvl int) []byte {
+	if TraceFilter.reshapes() {
+		return self.Frames().AppendIndentMultiTo(buf, lvl)
+	}
 	for _, val := range self {
 		buf = Caller(val).AppendNewlineIndentTo(buf, lvl)
 	}
@@ -148,9 +154,12 @@ func (self Caller) Pc() uintptr {
 	return uintptr(self) - 1
 }
 
-// Uses `runtime.FuncForPC` to return the function corresponding to this frame.
+/*
+Uses `runtime.FuncForPC` to return the function corresponding to this frame.
+Returns nil for callers decoded from JSON; see `Caller.IsForeign`.
+*/
 func (self Caller) Func() *runtime.Func {
-	if IsZero(self) {
+	if IsZero(self) || self.IsForeign() {
 		return nil
 	}
 	return runtime.FuncForPC(self.Pc())
@@ -193,7 +202,20 @@ func (self Frames) NameWidth() int {
 	return out
 }
 
+/*
+Appends a table-style representation of the frames, where each frame takes
+only one line, and names are aligned. Affected by `TraceFilter`.
+*/
 func (self Frames) AppendIndentTableTo(buf []byte, lvl int) []byte {
+	if TraceFilter.reshapes() {
+		rows := TraceFilter.rows(self)
+		wid := frameRowsNameWidth(rows)
+		for _, val := range rows {
+			buf = val.AppendRowIndentTo(buf, lvl, wid)
+		}
+		return buf
+	}
+
 	wid := self.NameWidth()
 	for _, val := range self {
 		buf = val.AppendRowIndentTo(buf, lvl, wid)
@@ -201,26 +223,56 @@ func (self Frames) AppendIndentTableTo(buf []byte, lvl int) []byte {
 	return buf
 }
 
+/*
+Appends a representation of the frames similar to the default used by the Go
+runtime. Affected by `TraceFilter`.
+*/
+func (self Frames) AppendIndentMultiTo(buf []byte, lvl int) []byte {
+	if TraceFilter.reshapes() {
+		for _, val := range TraceFilter.rows(self) {
+			buf = val.AppendNewlineIndentTo(buf, lvl)
+		}
+		return buf
+	}
+
+	for _, val := range self {
+		buf = val.AppendNewlineIndentTo(buf, lvl)
+	}
+	return buf
+}
+
 // Represents a stack frame. Generated by `Caller`. Used for formatting.
 type Frame struct {
-	Caller Caller
-	Func   *runtime.Func
-	Name   string
-	File   string
-	Line   int
+	Caller   Caller
+	Func     *runtime.Func
+	Name     string // Function name without package path prefix.
+	FullName string // Function name with package path prefix.
+	File     string
+	Line     int
 }
 
-// True if the frame has a known associated function.
-func (self Frame) IsValid() bool { return self.Func != nil }
+/*
+True if the frame has a known associated function. For frames decoded from
+JSON, `.Func` is always nil, and only the name is known.
+*/
+func (self Frame) IsValid() bool { return self.Func != nil || self.Name != `` }
 
 func (self *Frame) Init(val Caller) {
 	self.Caller = val
 
+	if val.IsForeign() {
+		src, _ := callersForeign.frame(val)
+		self.FullName, self.File, self.Line = src.Func, src.File, src.Line
+		self.Name = path.Base(src.Func)
+		return
+	}
+
 	fun := val.Func()
 	self.Func = fun
 
 	if fun != nil {
-		self.Name = FuncNameBase(fun)
+		self.FullName = fun.Name()
+		self.Name = path.Base(self.FullName)
 		self.File, self.Line = fun.FileLine(val.Pc())
 	}
 }
@@ -297,10 +349,13 @@ func (self Frame) AppendRowIndentTo(inout []byte, lvl, wid int) []byte {
 /*
 True if the frame should not be displayed, either because it's invalid, or
 because `TraceSkipLang` is set and the frame represents a "language" frame
-which is mostly not useful for debugging app code.
+which is mostly not useful for debugging app code, or because the frame is
+hidden by `TraceFilter`.
 */
 func (self *Frame) Skip() bool {
-	return !self.IsValid() || (TraceSkipLang && self.IsLang())
+	return !self.IsValid() ||
+		(TraceSkipLang && self.IsLang()) ||
+		!TraceFilter.Show(self)
 }
 
 /*
@@ -312,6 +367,25 @@ func (self *Frame) IsLang() bool {
 	return pkg == `runtime` || pkg == `testing`
 }
 
+/*
+Returns the package path of the given frame, such as "github.com/mitranim/gg".
+Also see `Frame.Pkg` which returns only the last segment.
+
+In function names, Go escapes dots in the last segment of the package path as
+"%2e", for example "gopkg.in/yaml%2ev3.Unmarshal". The dot which ends the
+package path is the first unescaped dot after the last slash. The returned
+path is unescaped: "gopkg.in/yaml.v3".
+*/
+func (self *Frame) PkgPath() string {
+	name := self.FullName
+	start := strings.LastIndexByte(name, '/') + 1
+	ind := strings.IndexByte(name[start:], '.')
+	if ind >= 0 {
+		name = name[:start+ind]
+	}
+	return strings.ReplaceAll(name, `%2e`, `.`)
+}
+
 // Returns the package name of the given frame.
 func (self *Frame) Pkg() string {
 	name := self.Name
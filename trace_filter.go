@@ -0,0 +1,191 @@
+package gg
+
+import "strings"
+
+/*
+Configures which stack frames are displayed when printing traces, in addition
+to the default filtering performed by `Frame.Skip`. Used via the global
+variable `TraceFilter`, which affects all trace printing, including
+`Err.AppendStackTo`, `Trace.AppendIndentTableTo`, and test failure messages
+printed by "gtest". The zero value has no effect. Usage:
+
+	gg.TraceFilter = gg.FrameFilter{
+		Deny:     []string{`github.com/some/middleware`},
+		Collapse: true,
+		MaxDepth: 32,
+	}
+*/
+type FrameFilter struct {
+	/**
+	Package path prefixes. When non-empty, only frames from matching packages
+	are displayed. A prefix matches the package with the same path and its
+	subpackages, such as "github.com/mitranim/gg/gtest" for the prefix
+	"github.com/mitranim/gg".
+	*/
+	Allow []string
+
+	/**
+	Package path prefixes. Frames from matching packages are hidden, even if
+	they match `.Allow`. Uses the same matching as `.Allow`.
+	*/
+	Deny []string
+
+	/**
+	When true, consecutive repetitions of a frame or a block of frames, which
+	typically come from recursion, are displayed once, followed by a marker
+	such as "... 12 repeated frames".
+	*/
+	Collapse bool
+
+	/**
+	When positive, only this many frames are displayed, followed by a marker
+	such as "... 12 more frames".
+	*/
+	MaxDepth int
+}
+
+/*
+True if the frame may be displayed according to `.Allow` and `.Deny`. Doesn't
+check the other conditions of `Frame.Skip`.
+*/
+func (self FrameFilter) Show(frame *Frame) bool {
+	if len(self.Allow) <= 0 && len(self.Deny) <= 0 {
+		return true
+	}
+
+	pkg := frame.PkgPath()
+	if Some(self.Deny, func(val string) bool { return isPkgPrefix(pkg, val) }) {
+		return false
+	}
+	return len(self.Allow) <= 0 ||
+		Some(self.Allow, func(val string) bool { return isPkgPrefix(pkg, val) })
+}
+
+func (self FrameFilter) reshapes() bool {
+	return self.Collapse || self.MaxDepth > 0
+}
+
+/*
+Converts the frames to a sequence of displayed rows, omitting skipped frames,
+and replacing collapsed and truncated frames with markers.
+*/
+func (self FrameFilter) rows(src Frames) (out []frameRow) {
+	frames := Reject(src, frameSkip)
+	var count int
+
+	for ind := 0; ind < len(frames); {
+		period, reps := 1, 0
+		if self.Collapse {
+			period, reps = framesRepeat(frames[ind:])
+		}
+
+		for _, val := range frames[ind : ind+period] {
+			if self.MaxDepth > 0 && count >= self.MaxDepth {
+				return append(out, frameRow{Elided: len(frames) - ind, Kind: `more`})
+			}
+			out = append(out, frameRow{Frame: val})
+			count++
+			ind++
+		}
+
+		if reps > 0 {
+			elided := period * reps
+			out = append(out, frameRow{Elided: elided, Kind: `repeated`})
+			ind += elided
+		}
+	}
+	return
+}
+
+func frameSkip(val Frame) bool { return val.Skip() }
+
+func isPkgPrefix(pkg, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, `/`)
+	return prefix != `` && strings.HasPrefix(pkg, prefix) &&
+		(len(pkg) == len(prefix) || pkg[len(prefix)] == '/')
+}
+
+// Max length of a block of frames detected as repeating by `FrameFilter`.
+const framesRepeatMaxPeriod = 8
+
+/*
+Finds the block of frames at the start of the given frames whose consecutive
+repetitions elide the most frames. Returns the length of the block and the
+count of repetitions following the first occurrence.
+*/
+func framesRepeat(src Frames) (period, reps int) {
+	period = 1
+	var best int
+
+	for wid := 1; wid <= framesRepeatMaxPeriod && wid*2 <= len(src); wid++ {
+		var count int
+		for (count+2)*wid <= len(src) &&
+			framesEq(src[:wid], src[(count+1)*wid:(count+2)*wid]) {
+			count++
+		}
+
+		if count*wid > best {
+			best = count * wid
+			period, reps = wid, count
+		}
+	}
+	return
+}
+
+func framesEq(one, two Frames) bool {
+	for ind := range one {
+		if one[ind].Caller != two[ind].Caller {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Either a frame or a marker replacing elided frames. Markers have a positive
+`.Elided` count.
+*/
+type frameRow struct {
+	Frame
+	Elided int
+	Kind   string
+}
+
+func (self frameRow) AppendRowIndentTo(inout []byte, lvl, wid int) []byte {
+	if self.Elided > 0 {
+		return self.appendMarkerTo(inout, lvl)
+	}
+	return self.Frame.AppendRowIndentTo(inout, lvl, wid)
+}
+
+func (self frameRow) AppendNewlineIndentTo(inout []byte, lvl int) []byte {
+	if self.Elided > 0 {
+		return self.appendMarkerTo(inout, lvl)
+	}
+	return self.Frame.AppendNewlineIndentTo(inout, lvl)
+}
+
+func (self frameRow) appendMarkerTo(inout []byte, lvl int) []byte {
+	buf := Buf(inout)
+	buf.AppendNewline()
+	buf.AppendIndents(lvl)
+	buf.AppendString(`... `)
+	buf.AppendInt(self.Elided)
+	buf.AppendSpace()
+	buf.AppendString(self.Kind)
+	if self.Elided == 1 {
+		buf.AppendString(` frame`)
+	} else {
+		buf.AppendString(` frames`)
+	}
+	return buf
+}
+
+func frameRowsNameWidth(src []frameRow) (out int) {
+	for _, val := range src {
+		if val.Elided <= 0 {
+			out = MaxPrim2(out, len(val.NameShort()))
+		}
+	}
+	return
+}
@@ -0,0 +1,146 @@
+package gg_test
+
+import (
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+func traceRec(count int) gg.Trace {
+	if count > 0 {
+		return traceRec(count - 1)
+	}
+	return gg.CaptureTrace(0)
+}
+
+func traceRecOne(count int) gg.Trace {
+	if count > 0 {
+		return traceRecTwo(count - 1)
+	}
+	return gg.CaptureTrace(0)
+}
+
+func traceRecTwo(count int) gg.Trace { return traceRecOne(count) }
+
+func TestFrame_PkgPath(t *testing.T) {
+	defer gtest.Catch(t)
+
+	test := func(src, exp string) {
+		t.Helper()
+		gtest.Eq((&gg.Frame{FullName: src}).PkgPath(), exp)
+	}
+
+	test(``, ``)
+	test(`main.main`, `main`)
+	test(`github.com/mitranim/gg.Try`, `github.com/mitranim/gg`)
+	test(`github.com/mitranim/gg.(*Err).Error`, `github.com/mitranim/gg`)
+	test(`github.com/mitranim/gg/gtest.Catch.func1`, `github.com/mitranim/gg/gtest`)
+	test(`gopkg.in/yaml%2ev3.Unmarshal`, `gopkg.in/yaml.v3`)
+	test(`gopkg.in/yaml%2ev3.(*Decoder).Decode.func1`, `gopkg.in/yaml.v3`)
+	test(`example.com/pp/foo%2ev2.Name`, `example.com/pp/foo.v2`)
+	gtest.Eq(trace0().Frames()[0].PkgPath(), `github.com/mitranim/gg_test`)
+}
+
+func TestFrameFilter_Show(t *testing.T) {
+	defer gtest.Catch(t)
+
+	show := func(filter gg.FrameFilter, name string) bool {
+		return filter.Show(&gg.Frame{FullName: name})
+	}
+
+	const (
+		one   = `github.com/mitranim/gg.Try`
+		two   = `github.com/mitranim/gg/gtest.Catch`
+		three = `github.com/mitranim/gg_test.trace0`
+		four  = `main.main`
+	)
+
+	gtest.True(show(gg.FrameFilter{}, one))
+	gtest.True(show(gg.FrameFilter{}, four))
+
+	filter := gg.FrameFilter{Deny: []string{`github.com/mitranim/gg`}}
+	gtest.False(show(filter, one))
+	gtest.False(show(filter, two))
+	gtest.True(show(filter, three))
+	gtest.True(show(filter, four))
+
+	filter = gg.FrameFilter{Allow: []string{`github.com/mitranim/gg/`}}
+	gtest.True(show(filter, one))
+	gtest.True(show(filter, two))
+	gtest.False(show(filter, three))
+	gtest.False(show(filter, four))
+
+	filter.Deny = []string{`github.com/mitranim/gg/gtest`}
+	gtest.True(show(filter, one))
+	gtest.False(show(filter, two))
+	gtest.False(show(filter, three))
+}
+
+func TestTraceFilter_deny(t *testing.T) {
+	defer gtest.Catch(t)
+	defer gg.SnapSwap(&gg.TraceFilter, gg.FrameFilter{
+		Deny: []string{`github.com/mitranim/gg_test`},
+	}).Done()
+
+	gtest.Zero(trace0().Table())
+	gtest.Eq(gg.Errf(`one`).Stack(), `one`)
+	gtest.Eq(gg.Wrap(gg.Errf(`two`), `one`).(gg.Err).Stack(), `one: two`)
+}
+
+func TestTraceFilter_MaxDepth(t *testing.T) {
+	defer gtest.Catch(t)
+	defer gg.SnapSwap(&gg.TraceFilter, gg.FrameFilter{MaxDepth: 3}).Done()
+
+	trace := trace0()
+
+	gtest.Str(trace.Table(), `
+gg_test.trace9 trace_test.go:20
+gg_test.trace8 trace_test.go:19
+gg_test.trace7 trace_test.go:18
+... 8 more frames`)
+
+	gg.TraceFilter.MaxDepth = 10
+	gtest.TextHas(trace.Table(), `
+gg_test.trace0 trace_test.go:11
+... 1 more frame`)
+
+	gg.TraceFilter.MaxDepth = 11
+	gtest.NotTextHas(trace.Table(), `more frame`)
+}
+
+func TestTraceFilter_Collapse(t *testing.T) {
+	defer gtest.Catch(t)
+	defer gg.SnapSwap(&gg.TraceFilter, gg.FrameFilter{Collapse: true}).Done()
+
+	gtest.Str(traceRec(5).Table(), `
+gg_test.traceRec                 trace_filter_test.go:14
+gg_test.traceRec                 trace_filter_test.go:12
+... 4 repeated frames
+gg_test.TestTraceFilter_Collapse trace_filter_test.go:116`)
+
+	gtest.Str(traceRecOne(5).Table(), `
+gg_test.traceRecOne              trace_filter_test.go:21
+gg_test.traceRecTwo              trace_filter_test.go:24
+gg_test.traceRecOne              trace_filter_test.go:19
+... 8 repeated frames
+gg_test.TestTraceFilter_Collapse trace_filter_test.go:122`)
+
+	gg.TraceFilter.MaxDepth = 2
+	gtest.Str(traceRecOne(5).Table(), `
+gg_test.traceRecOne trace_filter_test.go:21
+gg_test.traceRecTwo trace_filter_test.go:24
+... 10 more frames`)
+
+	defer gg.SnapSwap(&gg.TraceTable, false).Done()
+	gg.TraceFilter.MaxDepth = 0
+
+	gtest.Str(traceRec(5).String(), `
+gg_test.traceRec
+    trace_filter_test.go:14
+gg_test.traceRec
+    trace_filter_test.go:12
+... 4 repeated frames
+gg_test.TestTraceFilter_Collapse
+    trace_filter_test.go:138`)
+}
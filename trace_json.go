@@ -0,0 +1,134 @@
+package gg
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+/*
+Implement `json.Marshaler`. Encodes the trace as an array of frames resolved
+into full function name, file path, and line, which remain meaningful in other
+processes. Unresolvable frames are omitted. Also see `Trace.UnmarshalJSON`.
+*/
+func (self Trace) MarshalJSON() ([]byte, error) {
+	if self == nil {
+		return ToBytes(`null`), nil
+	}
+
+	out := make([]frameJson, 0, len(self))
+	for _, val := range self {
+		frame := Caller(val).Frame()
+		if frame.IsValid() {
+			out = append(out, frameJson{frame.FullName, frame.File, frame.Line})
+		}
+	}
+	return json.Marshal(out)
+}
+
+/*
+Implement `json.Unmarshaler`. Decodes the representation created by
+`Trace.MarshalJSON`. Because program counters from another process don't
+correspond to any code in this process, each decoded frame is assigned a
+synthetic program counter which is resolved via an internal registry rather
+than `runtime.FuncForPC`. This allows decoded traces to be printed, compared,
+and merged like local traces.
+
+The registry deduplicates frames and never releases them. Memory usage is
+proportional to the count of distinct frames ever decoded, which for traces
+produced by a finite set of programs is bounded by the size of their code.
+*/
+func (self *Trace) UnmarshalJSON(src []byte) error {
+	if IsJsonEmpty(src) {
+		*self = nil
+		return nil
+	}
+
+	var frames []frameJson
+	err := json.Unmarshal(src, &frames)
+	if err != nil {
+		return err
+	}
+
+	out := make(Trace, 0, len(frames))
+	for _, val := range frames {
+		caller := callersForeign.get(val)
+		if caller != 0 {
+			out = append(out, uintptr(caller))
+		}
+	}
+	*self = out
+	return nil
+}
+
+// True if the caller was created by decoding a trace via `Trace.UnmarshalJSON`.
+func (self Caller) IsForeign() bool {
+	return uintptr(self) > callerForeignFloor
+}
+
+type frameJson struct {
+	Func string `json:"func,omitempty"`
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+/*
+Synthetic program counters are allocated downward from the top of the address
+space, which is never occupied by program code.
+*/
+const callerForeignLimit = 1 << 20
+
+const callerForeignFloor = ^uintptr(0) - callerForeignLimit
+
+var callersForeign callers_foreign_t
+
+type callers_foreign_t struct {
+	lock   sync.RWMutex
+	frames []frameJson
+	index  map[frameJson]Caller
+}
+
+/*
+Returns a synthetic caller for the given frame, idempotently registering it.
+When the registry is full, returns 0, which causes the frame to be omitted.
+*/
+func (self *callers_foreign_t) get(val frameJson) Caller {
+	if val == (frameJson{}) {
+		return 0
+	}
+
+	out := self.got(val)
+	if out != 0 {
+		return out
+	}
+
+	defer Lock(&self.lock).Unlock()
+
+	out = self.index[val]
+	if out != 0 {
+		return out
+	}
+
+	ind := len(self.frames)
+	if ind >= callerForeignLimit {
+		return 0
+	}
+
+	out = Caller(^uintptr(0) - uintptr(ind))
+	self.frames = append(self.frames, val)
+	MapInit(&self.index)[val] = out
+	return out
+}
+
+func (self *callers_foreign_t) got(val frameJson) Caller {
+	defer Lock(self.lock.RLocker()).Unlock()
+	return self.index[val]
+}
+
+func (self *callers_foreign_t) frame(val Caller) (_ frameJson, _ bool) {
+	ind := ^uintptr(0) - uintptr(val)
+	defer Lock(self.lock.RLocker()).Unlock()
+	if ind < uintptr(len(self.frames)) {
+		return self.frames[ind], true
+	}
+	return
+}
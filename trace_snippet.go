@@ -0,0 +1,185 @@
+package gg
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+/*
+Returns a representation of the trace where each frame is followed by a few
+lines of source code around the frame's line, with the line itself marked.
+See `Trace.AppendIndentSnippetTo`.
+*/
+func (self Trace) Snippet() string { return self.SnippetIndent(0) }
+
+/*
+Returns a representation of the trace with source snippets and the given
+leading indentation. See `Trace.AppendIndentSnippetTo`.
+*/
+func (self Trace) SnippetIndent(lvl int) string {
+	return ToString(self.AppendIndentSnippetTo(nil, lvl))
+}
+
+/*
+Appends a representation of the trace where each frame is followed by
+`TraceSnippetLines` lines of source code before and after the frame's line,
+with the line itself marked by ">". Snippets are omitted for library frames
+(see `Frame.IsLib`) and for frames whose source files are unavailable. Source
+files are read lazily and cached for the lifetime of the process. Affected by
+the other "Trace*" variables, including `TraceFilter`.
+*/
+func (self Trace) AppendIndentSnippetTo(buf []byte, lvl int) []byte {
+	return self.Frames().AppendIndentSnippetTo(buf, lvl)
+}
+
+// Same as `Trace.AppendIndentSnippetTo`.
+func (self Frames) AppendIndentSnippetTo(inout []byte, lvl int) []byte {
+	buf := Buf(inout)
+	for _, val := range TraceFilter.rows(self) {
+		if val.Elided > 0 {
+			buf = val.appendMarkerTo(buf, lvl)
+			continue
+		}
+
+		buf.AppendNewline()
+		buf.AppendIndents(lvl)
+		buf = val.AppendTo(buf)
+
+		if !val.IsLib() {
+			buf = val.AppendSnippetIndentTo(buf, lvl+1)
+		}
+	}
+	return buf
+}
+
+/*
+Appends lines of source code around the frame's line, each preceded by a
+newline and the given indentation. See `Trace.AppendIndentSnippetTo`.
+*/
+func (self Frame) AppendSnippetIndentTo(inout []byte, lvl int) []byte {
+	buf := Buf(inout)
+	lines := traceSourceLines(self.File)
+	if self.Line <= 0 || self.Line > len(lines) {
+		return buf
+	}
+
+	start := MaxPrim2(self.Line-TraceSnippetLines, 1)
+	end := MinPrim2(self.Line+TraceSnippetLines, len(lines))
+	wid := len(strconv.Itoa(end))
+
+	for line := start; line <= end; line++ {
+		buf.AppendNewline()
+		buf.AppendIndents(lvl)
+
+		if line == self.Line {
+			buf.AppendString(`> `)
+		} else {
+			buf.AppendString(`  `)
+		}
+
+		size := len(buf)
+		buf.AppendInt(line)
+		buf.AppendSpaces(wid - (len(buf) - size))
+		buf.AppendString(` |`)
+
+		text := strings.TrimRight(lines[line-1], " \t\r")
+		if text != `` {
+			buf.AppendSpace()
+			buf.AppendString(strings.ReplaceAll(text, "\t", Indent))
+		}
+	}
+	return buf
+}
+
+/*
+True if the frame belongs to a library rather than to app code: either a
+"language" frame (see `Frame.IsLang`), or a frame whose source file is in the
+standard library sources under GOROOT or in the Go module cache.
+*/
+func (self *Frame) IsLib() bool {
+	return self.IsLang() ||
+		strings.Contains(self.File, `/pkg/mod/`) ||
+		(traceGorootSrc != `` && strings.HasPrefix(self.File, traceGorootSrc))
+}
+
+/*
+Directory of the standard library sources, such as "/usr/local/go/src/",
+derived from the source file of a runtime function. Empty in binaries built
+with "-trimpath", where standard library files are reported without a common
+prefix; they're not found by `traceSourceLines` either, and have no snippets
+regardless of `Frame.IsLib`.
+*/
+var traceGorootSrc = traceGorootSrcInit()
+
+func traceGorootSrcInit() string {
+	fun := RuntimeFunc(runtime.Gosched)
+	if fun == nil {
+		return ``
+	}
+
+	file, _ := fun.FileLine(fun.Entry())
+	ind := strings.LastIndex(file, `/runtime/`)
+	if ind < 0 {
+		return ``
+	}
+	return file[:ind+1]
+}
+
+var traceSources SyncMap[string, []string]
+
+/*
+Reads and caches the lines of the given source file. On failure, caches and
+returns nil, which causes snippets for that file to be omitted.
+*/
+func traceSourceLines(path string) []string {
+	if path == `` {
+		return nil
+	}
+
+	out, ok := traceSources.Load(path)
+	if ok {
+		return out
+	}
+
+	src, err := os.ReadFile(path)
+	if err == nil {
+		out = SplitLines(ToString(src))
+	}
+	out, _ = traceSources.LoadOrStore(path, out)
+	return out
+}
+
+/*
+Appends the stack of the given error, followed by source snippets of its
+trace, if any. Used by `ErrStackSnippet` and `Err.Format`.
+*/
+func errAppendStackSnippet(buf Buf, err error) Buf {
+	buf.AppendErrorStack(err)
+
+	trace := ErrTrace(err)
+	if trace.IsEmpty() {
+		return buf
+	}
+
+	size := len(buf)
+	buf.AppendNewline()
+	buf.AppendString(`source:`)
+	prev := len(buf)
+	buf = trace.AppendIndentSnippetTo(buf, 1)
+
+	if len(buf) == prev {
+		return buf[:size]
+	}
+	return buf
+}
+
+/*
+Similar to `ErrStack`, but the stack is followed by source snippets of the
+error's trace, as described in `Trace.AppendIndentSnippetTo`. Same as
+formatting an `Err` or `Errs` with `%+#v`.
+*/
+func ErrStackSnippet(err error) string {
+	return ToString(errAppendStackSnippet(nil, err))
+}
@@ -0,0 +1,101 @@
+package gg_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+func TestTrace_Snippet(t *testing.T) {
+	defer gtest.Catch(t)
+	defer gg.SnapSwap(&gg.TraceFilter, gg.FrameFilter{MaxDepth: 2}).Done()
+	defer gg.SnapSwap(&gg.TraceSnippetLines, 1).Done()
+
+	gtest.Str(trace0().Snippet(), `
+gg_test.trace9 trace_test.go:20
+      19 | func trace8() gg.Trace { return trace9() }
+    > 20 | func trace9() gg.Trace { return gg.CaptureTrace(0) }
+      21 |
+gg_test.trace8 trace_test.go:19
+      18 | func trace7() gg.Trace { return trace8() }
+    > 19 | func trace8() gg.Trace { return trace9() }
+      20 | func trace9() gg.Trace { return gg.CaptureTrace(0) }
+... 9 more frames`)
+
+	gtest.Zero(gg.Trace(nil).Snippet())
+}
+
+func TestFrame_AppendSnippetIndentTo(t *testing.T) {
+	defer gtest.Catch(t)
+
+	test := func(src gg.Frame) {
+		t.Helper()
+		gtest.Zero(src.AppendSnippetIndentTo(nil, 0))
+	}
+
+	test(gg.Frame{})
+	test(gg.Frame{File: `does_not_exist.go`, Line: 1})
+	test(gg.Frame{File: `trace_test.go`, Line: 0})
+	test(gg.Frame{File: `trace_test.go`, Line: 1 << 20})
+
+	gtest.Eq(
+		string(gg.Frame{File: `trace_test.go`, Line: 1}.AppendSnippetIndentTo(nil, 0)),
+		"\n> 1 | package gg_test\n  2 |\n  3 | import (",
+	)
+}
+
+func TestFrame_IsLib(t *testing.T) {
+	defer gtest.Catch(t)
+
+	test := func(exp bool, name, file string) {
+		t.Helper()
+		gtest.Eq((&gg.Frame{FullName: name, File: file}).IsLib(), exp)
+	}
+
+	// Such as "/usr/local/go/src".
+	fun := gg.RuntimeFunc(strconv.Itoa)
+	file, _ := fun.FileLine(fun.Entry())
+	src := filepath.Dir(filepath.Dir(file))
+
+	test(true, `runtime.gopanic`, src+`/runtime/panic.go`)
+	test(true, `testing.tRunner`, src+`/testing/testing.go`)
+	test(true, `net/http.HandlerFunc.ServeHTTP`, src+`/net/http/server.go`)
+	test(true, `github.com/some/lib.Func`, `/home/user/go/pkg/mod/github.com/some/lib@v1.0.0/lib.go`)
+	test(false, `main.main`, `/app/main.go`)
+	test(false, `github.com/some/app.Func`, `/app/app.go`)
+	test(false, `myapp.Func`, `/app/myapp.go`)
+	test(false, `myapp/sub.Func`, `/app/sub/sub.go`)
+
+	var frame gg.Frame
+	sort.Slice([]int{2, 1}, func(int, int) bool {
+		frame = gg.Find(gg.CaptureTrace(0).Frames(), func(val gg.Frame) bool {
+			return val.PkgPath() == `sort`
+		})
+		return false
+	})
+	gtest.True(frame.IsLib(), `standard library frames must be library frames`)
+}
+
+func TestErrStackSnippet(t *testing.T) {
+	defer gtest.Catch(t)
+
+	gtest.Zero(gg.ErrStackSnippet(nil))
+	gtest.Eq(gg.ErrStackSnippet(gg.Err{Msg: `one`}), `one`)
+
+	_, _, line, _ := runtime.Caller(0)
+	err := gg.Errf(`one`).TracedAt(0)
+	out := gg.ErrStackSnippet(err)
+
+	gtest.TextHas(out, err.Stack())
+	gtest.TextHas(out, "\nsource:\n    gg_test.TestErrStackSnippet")
+	gtest.TextHas(out, fmt.Sprintf("    > %v |     err := gg.Errf(`one`).TracedAt(0)", line+1))
+	gtest.Eq(fmt.Sprintf(`%+#v`, err), out)
+	gtest.Eq(fmt.Sprintf(`%+#v`, gg.Errs{nil, err}), out)
+	gtest.Eq(fmt.Sprintf(`%+v`, err), err.Stack())
+}
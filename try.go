@@ -159,6 +159,7 @@ func Rec(out *error) {
 	err := AnyErrTracedAt(recover(), 1)
 	if err != nil {
 		*out = err
+		recObserve(err, false)
 	}
 }
 
@@ -195,6 +196,7 @@ func RecN(out *error, skip int) {
 	err := AnyErrTracedAt(recover(), skip+1)
 	if err != nil {
 		*out = err
+		recObserve(err, false)
 	}
 }
 
@@ -212,9 +214,11 @@ func RecOnly(ptr *error, test func(error) bool) {
 
 	*ptr = err
 	if test != nil && test(err) {
+		recObserve(err, false)
 		return
 	}
 
+	recObserve(err, true)
 	panic(err)
 }
 
@@ -224,6 +228,7 @@ crash. Calls the provided function ONLY if the error is non-nil.
 */
 func RecWith(fun func(error)) {
 	err := AnyErrTracedAt(recover(), 1)
+	recObserve(err, false)
 	if err != nil && fun != nil {
 		fun(err)
 	}
@@ -315,8 +320,10 @@ test; re-panics on other non-nil errors. Idempotently adds a stack trace.
 func SkipOnly(test func(error) bool) {
 	err := AnyErrTracedAt(recover(), 1)
 	if err != nil && test != nil && test(err) {
+		recObserve(err, false)
 		return
 	}
+	recObserve(err, true)
 	Try(err)
 }
 
@@ -350,20 +357,30 @@ simply ensures that there's a stack trace, then re-panics.
 Caution: due to idiosyncrasies of `recover()`, this works ONLY when deferred
 directly. Anything other than `defer gg.Traced()` will NOT work.
 */
-func Traced() { TryErr(AnyErrTracedAt(recover(), 1)) }
+func Traced() {
+	err := AnyErrTracedAt(recover(), 1)
+	recObserve(err, true)
+	TryErr(err)
+}
 
 /*
 Must be deferred. Version of `Traced` that skips the given number of stack
 frames when generating a stack trace.
 */
-func TracedAt(skip int) { Try(AnyErrTracedAt(recover(), skip+1)) }
+func TracedAt(skip int) {
+	err := AnyErrTracedAt(recover(), skip+1)
+	recObserve(err, true)
+	Try(err)
+}
 
 /*
 Must be deferred. Runs the function only if there's no panic. Idempotently adds
 a stack trace.
 */
 func Ok(fun func()) {
-	Try(AnyErrTracedAt(recover(), 1))
+	err := AnyErrTracedAt(recover(), 1)
+	recObserve(err, true)
+	Try(err)
 	if fun != nil {
 		fun()
 	}
@@ -375,6 +392,7 @@ re-panics. Idempotently adds a stack trace.
 */
 func Fail(fun func(error)) {
 	err := AnyErrTracedAt(recover(), 1)
+	recObserve(err, true)
 	if err != nil && fun != nil {
 		fun(err)
 	}
@@ -387,6 +405,7 @@ panic or nil. If the error is non-nil, re-panics.
 */
 func Finally(fun func(error)) {
 	err := AnyErrTracedAt(recover(), 1)
+	recObserve(err, true)
 	if fun != nil {
 		fun(err)
 	}
@@ -403,6 +422,7 @@ func Trans(fun func(error) error) {
 	if err != nil && fun != nil {
 		err = fun(err)
 	}
+	recObserve(err, true)
 	Try(err)
 }
 
@@ -426,6 +446,7 @@ func TransOnly(test func(error) bool, trans func(error) error) {
 	if err != nil && test != nil && trans != nil && test(err) {
 		err = trans(err)
 	}
+	recObserve(err, true)
 	Try(err)
 }
 
@@ -438,7 +459,9 @@ idempotently adding a stack trace. The message is converted to a string via
 	defer gg.Detail(`unable to do A with B `, someEntity.Id)
 */
 func Detail(msg ...any) {
-	Try(Wrap(AnyErr(recover()), msg...))
+	err := Wrap(AnyErr(recover()), msg...)
+	recObserve(err, true)
+	Try(err)
 }
 
 /*
@@ -452,7 +475,9 @@ The first argument must be a hardcoded pattern string compatible with
 expression rather than a hardcoded string, use `Detail` instead.
 */
 func Detailf(pat string, arg ...any) {
-	Try(Wrapf(AnyErr(recover()), pat, arg...))
+	err := Wrapf(AnyErr(recover()), pat, arg...)
+	recObserve(err, true)
+	Try(err)
 }
 
 /*
@@ -464,6 +489,7 @@ func DetailOnlyf(test func(error) bool, pat string, arg ...any) {
 	if err != nil && test != nil && test(err) {
 		err = Wrapf(err, pat, arg...)
 	}
+	recObserve(err, true)
 	Try(err)
 }
 
@@ -483,8 +509,11 @@ easier to read trace. See our types `Err` and `Trace`. Usage example:
 func Fatal() {
 	val := recover()
 	if val != nil {
+		err := AnyToErrTracedAt(val, 1)
+		recObserve(err, false)
+
 		var buf Buf
-		buf = AnyToErrTracedAt(val, 1).AppendStackTo(buf)
+		buf = err.AppendStackTo(buf)
 		buf.AppendNewline()
 		Nop2(os.Stderr.Write(buf))
 		os.Exit(1)
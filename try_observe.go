@@ -0,0 +1,137 @@
+package gg
+
+import (
+	r "reflect"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+Describes a panic which was recovered and converted to an error by one of the
+functions in this package, such as `Rec`, `Catch`, `ConcCatch`, `Traced`.
+Passed to observers registered via `RecObserve`.
+*/
+type RecEvent struct {
+	/**
+	Recovered value converted to an error, with a stack trace. Same as the
+	error written, returned, or re-panicked by the recovering function.
+	*/
+	Err error
+
+	/**
+	Location of the recovered panic: the first frame below the panic which
+	doesn't belong to the Go runtime or to this package. For example, for
+	`gg.Try(err)`, this is the caller of `Try`. Zero if unknown.
+	*/
+	Site Caller
+
+	/**
+	True if the recovering function re-panicked with the error, as done by
+	`Traced`, `Fail`, `Trans`, `Detail` and others. A single panic may be
+	observed multiple times: once for each re-panic, and once more when it's
+	finally handled.
+	*/
+	Reraised bool
+}
+
+/*
+Registers a function to be called whenever a function in this package recovers
+a panic and converts it to an error. Returns a function which unregisters the
+observer. Observers are called synchronously, in the order of registration, on
+the goroutine which recovered the panic, and must not panic; their panics are
+ignored. Intended for metrics, sampling, logging. Registration is safe for
+concurrent use. When no observers are registered, the overhead of recovering
+panics is a single atomic load. Usage:
+
+	func init() {
+		gg.RecObserve(func(val gg.RecEvent) {
+			if !val.Reraised {
+				metrics.PanicsRecovered.Inc()
+			}
+		})
+	}
+*/
+func RecObserve(fun func(RecEvent)) func() {
+	if fun == nil {
+		return Nop
+	}
+
+	obs := &recObserver{fun}
+
+	defer Lock(&recObservers.lock).Unlock()
+	prev := PtrGet(recObservers.list.Load())
+	next := CloneAppend(prev, obs)
+	recObservers.list.Store(&next)
+
+	return obs.unregister
+}
+
+var recObservers struct {
+	lock sync.Mutex
+	list atomic.Pointer[[]*recObserver]
+}
+
+type recObserver struct{ fun func(RecEvent) }
+
+func (self *recObserver) unregister() {
+	defer Lock(&recObservers.lock).Unlock()
+
+	prev := PtrGet(recObservers.list.Load())
+	ind := FindIndex(prev, self.is)
+	if ind < 0 {
+		return
+	}
+
+	next := Concat(prev[:ind], prev[ind+1:])
+	if len(next) <= 0 {
+		recObservers.list.Store(nil)
+		return
+	}
+	recObservers.list.Store(&next)
+}
+
+func (self *recObserver) is(val *recObserver) bool { return self == val }
+
+func (self *recObserver) run(val RecEvent) {
+	defer Skip()
+	self.fun(val)
+}
+
+/*
+Notifies the observers registered via `RecObserve`, if any. Must be called
+directly by the function which recovered the panic.
+*/
+func recObserve(err error, reraised bool) {
+	if err == nil {
+		return
+	}
+
+	list := recObservers.list.Load()
+	if list == nil {
+		return
+	}
+
+	event := RecEvent{Err: err, Site: recSite(), Reraised: reraised}
+	for _, val := range *list {
+		val.run(event)
+	}
+}
+
+func recSite() Caller {
+	var found bool
+
+	for _, val := range CaptureTrace(2) {
+		frame := Caller(val).Frame()
+		if !found {
+			found = frame.FullName == `runtime.gopanic`
+			continue
+		}
+
+		if frame.IsValid() && frame.Pkg() != `runtime` && frame.PkgPath() != pkgPath {
+			return frame.Caller
+		}
+	}
+	return 0
+}
+
+var pkgPath = r.TypeOf(Err{}).PkgPath()
@@ -0,0 +1,147 @@
+package gg_test
+
+import (
+	"io"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/mitranim/gg"
+	"github.com/mitranim/gg/gtest"
+)
+
+type recEvents struct {
+	sync.Mutex
+	Vals []gg.RecEvent
+}
+
+func (self *recEvents) Add(val gg.RecEvent) {
+	defer gg.Lock(self).Unlock()
+	self.Vals = append(self.Vals, val)
+}
+
+func (self *recEvents) Take() []gg.RecEvent {
+	defer gg.Lock(self).Unlock()
+	out := self.Vals
+	self.Vals = nil
+	return out
+}
+
+func TestRecObserve(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var events recEvents
+	defer gg.RecObserve(events.Add)()
+
+	t.Run(`catch`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		_, _, line, _ := runtime.Caller(0)
+		err := gg.Catch(func() { panic(io.EOF) })
+		vals := events.Take()
+
+		gtest.Len(vals, 1)
+		gtest.Equal(vals[0].Err, err)
+		gtest.False(vals[0].Reraised)
+
+		frame := vals[0].Site.Frame()
+		gtest.Eq(frame.Name, `gg_test.TestRecObserve.func1.1`)
+		gtest.Eq(frame.Line, line+1)
+	})
+
+	t.Run(`try`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		_, _, line, _ := runtime.Caller(0)
+		gg.Nop1(gg.Catch(func() { gg.Try(io.EOF) }))
+		vals := events.Take()
+
+		gtest.Len(vals, 1)
+		gtest.Eq(vals[0].Site.Frame().Line, line+1)
+	})
+
+	t.Run(`reraised`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		var line int
+		err := gg.Catch(func() {
+			defer gg.Traced()
+			_, _, line, _ = runtime.Caller(0)
+			panic(io.EOF)
+		})
+		vals := events.Take()
+
+		gtest.Len(vals, 2)
+		gtest.True(vals[0].Reraised)
+		gtest.False(vals[1].Reraised)
+		gtest.Equal(vals[1].Err, err)
+		gtest.Eq(vals[0].Site.Frame().Line, line+1)
+		gtest.Eq(vals[1].Site.Frame().Line, line+1)
+	})
+
+	t.Run(`conc`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		gg.Nop1(gg.ConcCatch(
+			func() { panic(io.EOF) },
+			func() {},
+			func() { panic(io.ErrUnexpectedEOF) },
+		))
+		vals := events.Take()
+
+		gtest.Len(vals, 2)
+		gtest.True(gg.Every(vals, isRecEventOk))
+	})
+
+	t.Run(`no_panic`, func(t *testing.T) {
+		defer gtest.Catch(t)
+
+		gtest.NoErr(gg.Catch(gg.Nop))
+		gtest.Zero(events.Take())
+	})
+}
+
+func isRecEventOk(val gg.RecEvent) bool {
+	return val.Err != nil && !val.Reraised && val.Site != 0
+}
+
+func TestRecObserve_unregister(t *testing.T) {
+	defer gtest.Catch(t)
+
+	var one, two recEvents
+	doneOne := gg.RecObserve(one.Add)
+	doneTwo := gg.RecObserve(two.Add)
+	defer doneTwo()
+
+	// Observer panics must be ignored.
+	defer gg.RecObserve(func(gg.RecEvent) { panic(`fail`) })()
+
+	gg.Nop1(gg.Catch(func() { panic(io.EOF) }))
+	gtest.Len(one.Take(), 1)
+	gtest.Len(two.Take(), 1)
+
+	doneOne()
+	doneOne()
+
+	gg.Nop1(gg.Catch(func() { panic(io.EOF) }))
+	gtest.Zero(one.Take())
+	gtest.Len(two.Take(), 1)
+
+	gg.RecObserve(nil)()
+}
+
+func BenchmarkCatch_panic_unobserved(b *testing.B) {
+	for ind := 0; ind < b.N; ind++ {
+		gg.Nop1(gg.Catch(benchmarkPanic))
+	}
+}
+
+func BenchmarkCatch_panic_observed(b *testing.B) {
+	defer gg.RecObserve(func(gg.RecEvent) {})()
+
+	for ind := 0; ind < b.N; ind++ {
+		gg.Nop1(gg.Catch(benchmarkPanic))
+	}
+}
+
+func benchmarkPanic() { panic(io.EOF) }